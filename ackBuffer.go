@@ -0,0 +1,54 @@
+package redis
+
+import "sync"
+
+type ackBuffer struct {
+	size int
+
+	mutex sync.Mutex
+	acks  map[string][]string // stream -> message IDs
+	dels  map[string][]string // stream -> message IDs
+	count int
+}
+
+func newAckBuffer(size int) *ackBuffer {
+	return &ackBuffer{
+		size: size,
+		acks: make(map[string][]string),
+		dels: make(map[string][]string),
+	}
+}
+
+func (b *ackBuffer) ack(stream, id string) (full bool) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.acks[stream] = append(b.acks[stream], id)
+	b.count++
+	return b.count >= b.size
+}
+
+func (b *ackBuffer) del(stream, id string) (full bool) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.dels[stream] = append(b.dels[stream], id)
+	b.count++
+	return b.count >= b.size
+}
+
+// drain removes and returns all buffered message IDs.
+func (b *ackBuffer) drain() (acks map[string][]string, dels map[string][]string) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if b.count == 0 {
+		return nil, nil
+	}
+
+	acks, dels = b.acks, b.dels
+	b.acks = make(map[string][]string)
+	b.dels = make(map[string][]string)
+	b.count = 0
+	return acks, dels
+}
@@ -0,0 +1,51 @@
+package redis
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestAckBuffer(t *testing.T) {
+	buffer := newAckBuffer(4)
+
+	expectedAndGot := [][]bool{
+		// run ack()/del()                        , expected
+		{buffer.ack("gotestStream1", "1-0"), false},
+		{buffer.ack("gotestStream2", "1-0"), false},
+		{buffer.del("gotestStream1", "1-0"), false},
+		{buffer.ack("gotestStream1", "2-0"), true},
+	}
+
+	for i, v := range expectedAndGot {
+		expected, got := v[1], v[0]
+		if expected != got {
+			t.Errorf("assert full at %d :: expected %+v, got %+v", (i + 1), expected, got)
+		}
+	}
+
+	acks, dels := buffer.drain()
+	{
+		expectedAcks := map[string][]string{
+			"gotestStream1": {"1-0", "2-0"},
+			"gotestStream2": {"1-0"},
+		}
+		if !reflect.DeepEqual(expectedAcks, acks) {
+			t.Errorf("ackBuffer.drain() acks expect:: %v, got:: %v\n", expectedAcks, acks)
+		}
+		expectedDels := map[string][]string{
+			"gotestStream1": {"1-0"},
+		}
+		if !reflect.DeepEqual(expectedDels, dels) {
+			t.Errorf("ackBuffer.drain() dels expect:: %v, got:: %v\n", expectedDels, dels)
+		}
+	}
+
+	acks, dels = buffer.drain()
+	if acks != nil || dels != nil {
+		t.Errorf("ackBuffer.drain() should return nil after drained, got:: %v, %v\n", acks, dels)
+	}
+
+	if buffer.ack("gotestStream1", "3-0") {
+		t.Errorf("ackBuffer.ack() should not be full after drained\n")
+	}
+}
@@ -1,7 +1,11 @@
 package redis
 
 import (
+	"fmt"
+	"time"
+
 	redis "github.com/go-redis/redis/v7"
+	"golang.org/x/exp/slices"
 )
 
 type AdminClient struct {
@@ -48,4 +52,299 @@ func (c *AdminClient) DeleteConsumer(stream, group, consumer string) (int64, err
 	return c.handle.XGroupDelConsumer(stream, group, consumer).Result()
 }
 
-// TODO: it might be add commands like XINFO, XLEN, XTRIM, XPENDING, XRANGE, XREVRANGE
+// StreamInfo returns the information of the stream by XINFO STREAM.
+func (c *AdminClient) StreamInfo(stream string) (*StreamInfo, error) {
+	reply, err := c.handle.Do("xinfo", "stream", stream).Result()
+	if err != nil {
+		return nil, err
+	}
+	return parseStreamInfo(reply)
+}
+
+// StreamInfoFull returns the entries, the groups, the consumers and the
+// pending entries of the stream by XINFO STREAM FULL. The count limits the
+// number of entries and pending entries returned, zero means no limit and
+// negative means the server default.
+func (c *AdminClient) StreamInfoFull(stream string, count int64) (*StreamInfoFull, error) {
+	args := []interface{}{"xinfo", "stream", stream, "full"}
+	if count >= 0 {
+		args = append(args, "count", count)
+	}
+
+	reply, err := c.handle.Do(args...).Result()
+	if err != nil {
+		return nil, err
+	}
+	return parseStreamInfoFull(reply)
+}
+
+// GroupsInfo returns the consumer groups of the stream by XINFO GROUPS.
+func (c *AdminClient) GroupsInfo(stream string) ([]GroupInfo, error) {
+	reply, err := c.handle.Do("xinfo", "groups", stream).Result()
+	if err != nil {
+		return nil, err
+	}
+	replies, ok := reply.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("got %T, wanted []interface{}", reply)
+	}
+
+	var groups = make([]GroupInfo, 0, len(replies))
+	for _, r := range replies {
+		group, err := parseGroupInfo(r)
+		if err != nil {
+			return nil, err
+		}
+		groups = append(groups, *group)
+	}
+	return groups, nil
+}
+
+// ConsumersInfo returns the consumers of the group by XINFO CONSUMERS.
+func (c *AdminClient) ConsumersInfo(stream, group string) ([]ConsumerInfo, error) {
+	reply, err := c.handle.Do("xinfo", "consumers", stream, group).Result()
+	if err != nil {
+		return nil, err
+	}
+	replies, ok := reply.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("got %T, wanted []interface{}", reply)
+	}
+
+	var consumers = make([]ConsumerInfo, 0, len(replies))
+	for _, r := range replies {
+		consumer, err := parseConsumerInfo(r)
+		if err != nil {
+			return nil, err
+		}
+		consumers = append(consumers, *consumer)
+	}
+	return consumers, nil
+}
+
+func (c *AdminClient) Length(stream string) (int64, error) {
+	return c.handle.XLen(stream).Result()
+}
+
+// TrimByMaxLen evicts the oldest entries of the stream until its length
+// is not greater than the maxLen. The approximate mode (~) trims the stream
+// efficiently but might leave a few more entries. It returns the number of
+// the deleted entries.
+func (c *AdminClient) TrimByMaxLen(stream string, maxLen int64, approximate bool) (int64, error) {
+	args := append([]interface{}{"xtrim", stream}, trimArgs("maxlen", maxLen, approximate)...)
+	return c.handle.Do(args...).Int64()
+}
+
+// TrimByMinID evicts the entries whose ID is lower than the minID, it is
+// available since Redis 6.2. It returns the number of the deleted entries.
+func (c *AdminClient) TrimByMinID(stream, minID string, approximate bool) (int64, error) {
+	args := append([]interface{}{"xtrim", stream}, trimArgs("minid", minID, approximate)...)
+	return c.handle.Do(args...).Int64()
+}
+
+// Trim trims the stream by the retention, e.g. keeping the entries of the
+// last 24 hours by StreamRetention.MaxAge.
+func (c *AdminClient) Trim(stream string, retention StreamRetention) (int64, error) {
+	if retention.IsZero() {
+		return 0, nil
+	}
+	trim, err := retention.args(time.Now())
+	if err != nil {
+		return 0, err
+	}
+	args := append([]interface{}{"xtrim", stream}, trim...)
+	return c.handle.Do(args...).Int64()
+}
+
+// Pending returns the summary of the pending entries of the group.
+func (c *AdminClient) Pending(stream, group string) (*XPending, error) {
+	return c.handle.XPending(stream, group).Result()
+}
+
+// PendingExt returns the pending entries of the group in the range.
+func (c *AdminClient) PendingExt(args *XPendingExtArgs) ([]XPendingExt, error) {
+	return c.handle.XPendingExt(args).Result()
+}
+
+// Range returns the entries of the stream between start and stop in
+// ascending order. The count limits the number of the entries returned,
+// zero means no limit.
+//
+// The messages are read-only, they are not delivered to any group, thus
+// Ack(), Del(), Nack() and Retry() take no effects.
+func (c *AdminClient) Range(stream, start, stop string, count int64) ([]*Message, error) {
+	var cmd *redis.XMessageSliceCmd
+	if count > 0 {
+		cmd = c.handle.XRangeN(stream, start, stop, count)
+	} else {
+		cmd = c.handle.XRange(stream, start, stop)
+	}
+	return c.readOnlyMessages(stream, cmd)
+}
+
+// RevRange returns the entries of the stream between stop and start in
+// descending order. See also Range().
+func (c *AdminClient) RevRange(stream, stop, start string, count int64) ([]*Message, error) {
+	var cmd *redis.XMessageSliceCmd
+	if count > 0 {
+		cmd = c.handle.XRevRangeN(stream, stop, start, count)
+	} else {
+		cmd = c.handle.XRevRange(stream, stop, start)
+	}
+	return c.readOnlyMessages(stream, cmd)
+}
+
+// Lag reports the lag and the pending messages of the consumer groups of the
+// stream. All the groups of the stream are reported if no group specified.
+func (c *AdminClient) Lag(stream string, groups ...string) ([]GroupLag, error) {
+	streamInfo, err := c.StreamInfo(stream)
+	if err != nil {
+		return nil, err
+	}
+
+	lastGeneratedID := streamInfo.LastGeneratedID
+	if len(lastGeneratedID) == 0 {
+		lastGeneratedID, err = c.lastEntryID(stream)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	groupInfos, err := c.GroupsInfo(stream)
+	if err != nil {
+		return nil, err
+	}
+
+	var lags = make([]GroupLag, 0, len(groupInfos))
+	for _, groupInfo := range groupInfos {
+		if len(groups) > 0 && !slices.Contains(groups, groupInfo.Name) {
+			continue
+		}
+
+		lag := GroupLag{
+			Stream:          stream,
+			Group:           groupInfo.Name,
+			Length:          streamInfo.Length,
+			LastGeneratedID: lastGeneratedID,
+			LastDeliveredID: groupInfo.LastDeliveredID,
+		}
+
+		// NOTE: the lag is available since Redis 7.0, and it is nil if it
+		// cannot be determined, e.g. the entries have been deleted.
+		if groupInfo.Lag >= 0 {
+			lag.Lag = groupInfo.Lag
+		} else {
+			lag.Lag, lag.LagTruncated, err = c.countEntriesAfter(stream, lag.LastDeliveredID, lastGeneratedID)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		if err := c.fillPending(&lag); err != nil {
+			return nil, err
+		}
+		lags = append(lags, lag)
+	}
+	return lags, nil
+}
+
+func (c *AdminClient) fillPending(lag *GroupLag) error {
+	pending, err := c.handle.XPending(lag.Stream, lag.Group).Result()
+	if err != nil {
+		if err != redis.Nil {
+			return err
+		}
+		return nil
+	}
+
+	lag.Pending = pending.Count
+	lag.ConsumerPending = pending.Consumers
+	if pending.Count == 0 {
+		return nil
+	}
+
+	oldest, err := c.handle.XPendingExt(&redis.XPendingExtArgs{
+		Stream: lag.Stream,
+		Group:  lag.Group,
+		Start:  "-",
+		End:    "+",
+		Count:  1,
+	}).Result()
+	if err != nil {
+		if err != redis.Nil {
+			return err
+		}
+	}
+	if len(oldest) > 0 {
+		lag.OldestPendingIdle = oldest[0].Idle
+	}
+	return nil
+}
+
+func (c *AdminClient) lastEntryID(stream string) (string, error) {
+	messages, err := c.handle.XRevRangeN(stream, "+", "-", 1).Result()
+	if err != nil {
+		if err != redis.Nil {
+			return "", err
+		}
+	}
+	if len(messages) == 0 {
+		return "", nil
+	}
+	return messages[0].ID, nil
+}
+
+// countEntriesAfter counts the entries whose ID is greater than the id by
+// XRANGE, it stops counting at MAX_LAG_SCAN_SIZE.
+func (c *AdminClient) countEntriesAfter(stream, id, lastID string) (count int64, truncated bool, err error) {
+	if len(lastID) == 0 || id == lastID {
+		return 0, false, nil
+	}
+
+	var start = StreamZeroID
+	if len(id) > 0 && id != "0-0" {
+		start, err = nextStreamID(id)
+		if err != nil {
+			return 0, false, err
+		}
+	}
+
+	for count < MAX_LAG_SCAN_SIZE {
+		messages, err := c.handle.XRangeN(stream, start, "+", LAG_SCAN_PAGE_SIZE).Result()
+		if err != nil {
+			if err != redis.Nil {
+				return 0, false, err
+			}
+		}
+
+		count += int64(len(messages))
+		if int64(len(messages)) < LAG_SCAN_PAGE_SIZE {
+			return count, false, nil
+		}
+
+		start, err = nextStreamID(messages[len(messages)-1].ID)
+		if err != nil {
+			return 0, false, err
+		}
+	}
+	return MAX_LAG_SCAN_SIZE, true, nil
+}
+
+func (c *AdminClient) readOnlyMessages(stream string, cmd *redis.XMessageSliceCmd) ([]*Message, error) {
+	entries, err := cmd.Result()
+	if err != nil {
+		if err != redis.Nil {
+			return nil, err
+		}
+	}
+
+	var messages = make([]*Message, len(entries))
+	for i := range entries {
+		messages[i] = &Message{
+			XMessage: &entries[i],
+			Stream:   stream,
+			Delegate: readOnlyMessageDelegate{},
+		}
+	}
+	return messages, nil
+}
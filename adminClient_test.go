@@ -0,0 +1,217 @@
+package redis
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAdminClient_StreamInfo(t *testing.T) {
+	ids, teardown := setupMonitorTestStream()
+	defer teardown()
+
+	admin, err := NewAdminClient(&UniversalOptions{
+		Addrs: []string{__TEST_REDIS_SERVER},
+		DB:    0,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer admin.Close()
+
+	{
+		info, err := admin.StreamInfo("gotestStream1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		var expectedLength int64 = 4
+		if expectedLength != info.Length {
+			t.Errorf("StreamInfo.Length expect:: %v, got:: %v\n", expectedLength, info.Length)
+		}
+	}
+
+	{
+		groups, err := admin.GroupsInfo("gotestStream1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(groups) != 1 {
+			t.Fatalf("expect %d groups, but got %d groups", 1, len(groups))
+		}
+		var expectedName string = "gotestGroup"
+		if expectedName != groups[0].Name {
+			t.Errorf("GroupInfo.Name expect:: %v, got:: %v\n", expectedName, groups[0].Name)
+		}
+		var expectedPending int64 = 2
+		if expectedPending != groups[0].Pending {
+			t.Errorf("GroupInfo.Pending expect:: %v, got:: %v\n", expectedPending, groups[0].Pending)
+		}
+		var expectedLastDeliveredID string = ids[1]
+		if expectedLastDeliveredID != groups[0].LastDeliveredID {
+			t.Errorf("GroupInfo.LastDeliveredID expect:: %v, got:: %v\n", expectedLastDeliveredID, groups[0].LastDeliveredID)
+		}
+	}
+
+	{
+		consumers, err := admin.ConsumersInfo("gotestStream1", "gotestGroup")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(consumers) != 1 {
+			t.Fatalf("expect %d consumers, but got %d consumers", 1, len(consumers))
+		}
+		var expectedName string = "gotestConsumer"
+		if expectedName != consumers[0].Name {
+			t.Errorf("ConsumerInfo.Name expect:: %v, got:: %v\n", expectedName, consumers[0].Name)
+		}
+		var expectedPending int64 = 2
+		if expectedPending != consumers[0].Pending {
+			t.Errorf("ConsumerInfo.Pending expect:: %v, got:: %v\n", expectedPending, consumers[0].Pending)
+		}
+	}
+}
+
+func TestAdminClient_Pending(t *testing.T) {
+	ids, teardown := setupMonitorTestStream()
+	defer teardown()
+
+	admin, err := NewAdminClient(&UniversalOptions{
+		Addrs: []string{__TEST_REDIS_SERVER},
+		DB:    0,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer admin.Close()
+
+	pending, err := admin.Pending("gotestStream1", "gotestGroup")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var expectedCount int64 = 2
+	if expectedCount != pending.Count {
+		t.Errorf("XPending.Count expect:: %v, got:: %v\n", expectedCount, pending.Count)
+	}
+	if ids[0] != pending.Lower {
+		t.Errorf("XPending.Lower expect:: %v, got:: %v\n", ids[0], pending.Lower)
+	}
+
+	entries, err := admin.PendingExt(&XPendingExtArgs{
+		Stream: "gotestStream1",
+		Group:  "gotestGroup",
+		Start:  "-",
+		End:    "+",
+		Count:  10,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("expect %d pending entries, but got %d pending entries", 2, len(entries))
+	}
+	if ids[1] != entries[1].ID {
+		t.Errorf("XPendingExt.ID expect:: %v, got:: %v\n", ids[1], entries[1].ID)
+	}
+}
+
+func TestAdminClient_Range(t *testing.T) {
+	ids, teardown := setupMonitorTestStream()
+	defer teardown()
+
+	admin, err := NewAdminClient(&UniversalOptions{
+		Addrs: []string{__TEST_REDIS_SERVER},
+		DB:    0,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer admin.Close()
+
+	{
+		messages, err := admin.Range("gotestStream1", "-", "+", 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(messages) != 4 {
+			t.Fatalf("expect %d messages, but got %d messages", 4, len(messages))
+		}
+		for i, msg := range messages {
+			if ids[i] != msg.ID {
+				t.Errorf("Message.ID expect:: %v, got:: %v\n", ids[i], msg.ID)
+			}
+			if msg.Stream != "gotestStream1" {
+				t.Errorf("Message.Stream expect:: %v, got:: %v\n", "gotestStream1", msg.Stream)
+			}
+		}
+
+		content := messages[0].Content()
+		var expectedName = "luffy"
+		if v := content.Values["name"]; expectedName != v {
+			t.Errorf("MessageContent.Values[name] expect:: %v, got:: %v\n", expectedName, v)
+		}
+		// the read-only messages cannot be acknowledged
+		messages[0].Ack()
+	}
+
+	{
+		messages, err := admin.RevRange("gotestStream1", "+", "-", 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(messages) != 2 {
+			t.Fatalf("expect %d messages, but got %d messages", 2, len(messages))
+		}
+		if ids[3] != messages[0].ID {
+			t.Errorf("Message.ID expect:: %v, got:: %v\n", ids[3], messages[0].ID)
+		}
+	}
+}
+
+func TestAdminClient_Trim(t *testing.T) {
+	ids, teardown := setupMonitorTestStream()
+	defer teardown()
+
+	admin, err := NewAdminClient(&UniversalOptions{
+		Addrs: []string{__TEST_REDIS_SERVER},
+		DB:    0,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer admin.Close()
+
+	deleted, err := admin.TrimByMaxLen("gotestStream1", 3, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var expectedDeleted int64 = 1
+	if expectedDeleted != deleted {
+		t.Errorf("AdminClient.TrimByMaxLen() expect:: %v, got:: %v\n", expectedDeleted, deleted)
+	}
+
+	deleted, err = admin.TrimByMinID("gotestStream1", ids[3], false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedDeleted = 2
+	if expectedDeleted != deleted {
+		t.Errorf("AdminClient.TrimByMinID() expect:: %v, got:: %v\n", expectedDeleted, deleted)
+	}
+
+	deleted, err = admin.Trim("gotestStream1", StreamRetention{MaxAge: time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedDeleted = 0
+	if expectedDeleted != deleted {
+		t.Errorf("AdminClient.Trim() expect:: %v, got:: %v\n", expectedDeleted, deleted)
+	}
+
+	length, err := admin.Length("gotestStream1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var expectedLength int64 = 1
+	if expectedLength != length {
+		t.Errorf("AdminClient.Length() expect:: %v, got:: %v\n", expectedLength, length)
+	}
+}
@@ -0,0 +1,274 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	redis "github.com/go-redis/redis/v7"
+)
+
+// AsyncProducer writes the messages in background, the messages are queued
+// and sent in a pipeline when the batch is full or the linger time elapses.
+// The results are reported by the DeliveryHandler and Deliveries().
+type AsyncProducer struct {
+	producer *Producer
+
+	batchSize       int
+	lingerTime      time.Duration
+	backpressure    BackpressurePolicy
+	deliveryHandler DeliveryReportHandleProc
+
+	queue      chan *BatchMessage
+	flushCh    chan chan struct{}
+	closing    chan struct{} // closed on Close to release the blocked writers
+	stop       chan struct{}
+	deliveries chan *DeliveryReport
+	dropped    int64 // the reports dropped since Deliveries() is full
+
+	wg       sync.WaitGroup
+	writers  sync.WaitGroup
+	mutex    sync.RWMutex
+	disposed bool
+}
+
+func NewAsyncProducer(config *AsyncProducerConfig) (*AsyncProducer, error) {
+	producer, err := NewProducer(&config.ProducerConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	instance := &AsyncProducer{
+		producer:        producer,
+		batchSize:       config.BatchSize,
+		lingerTime:      config.LingerTime,
+		backpressure:    config.Backpressure,
+		deliveryHandler: config.DeliveryHandler,
+		flushCh:         make(chan chan struct{}),
+		closing:         make(chan struct{}),
+		stop:            make(chan struct{}),
+	}
+	if instance.batchSize <= 0 {
+		instance.batchSize = DEFAULT_ASYNC_BATCH_SIZE
+	}
+	if instance.lingerTime <= 0 {
+		instance.lingerTime = DEFAULT_ASYNC_LINGER_TIME
+	}
+
+	var queueSize = config.QueueSize
+	if queueSize <= 0 {
+		queueSize = DEFAULT_ASYNC_QUEUE_SIZE
+	}
+	instance.queue = make(chan *BatchMessage, queueSize)
+	if config.ReturnDeliveries {
+		instance.deliveries = make(chan *DeliveryReport, queueSize)
+	}
+
+	instance.wg.Add(1)
+	go instance.run()
+
+	return instance, nil
+}
+
+func (p *AsyncProducer) Handle() redis.UniversalClient {
+	return p.producer.Handle()
+}
+
+// Deliveries returns the channel of the delivery reports if
+// ReturnDeliveries is set, it is closed after the AsyncProducer closed.
+// NOTE: the reports are dropped if the channel is full, drain it or use
+// the DeliveryHandler instead. See also DroppedDeliveries().
+func (p *AsyncProducer) Deliveries() <-chan *DeliveryReport {
+	return p.deliveries
+}
+
+// DroppedDeliveries returns the number of the delivery reports dropped
+// since Deliveries() is full.
+func (p *AsyncProducer) DroppedDeliveries() int64 {
+	return atomic.LoadInt64(&p.dropped)
+}
+
+// WriteAsync queues the message. It blocks, drops the message or returns
+// ErrQueueFull by the Backpressure when the queue is full, the dropped
+// message is reported with ErrQueueFull.
+func (p *AsyncProducer) WriteAsync(stream string, values map[string]interface{}, opts ...ProduceMessageOption) error {
+	p.mutex.RLock()
+	if p.disposed {
+		p.mutex.RUnlock()
+		return fmt.Errorf("the AsyncProducer has been disposed")
+	}
+	p.writers.Add(1)
+	p.mutex.RUnlock()
+
+	defer p.writers.Done()
+
+	msg := &BatchMessage{
+		Stream:  stream,
+		Values:  values,
+		Options: opts,
+	}
+
+	switch p.backpressure {
+	case BackpressureDrop, BackpressureError:
+		select {
+		case p.queue <- msg:
+		default:
+			if p.backpressure == BackpressureError {
+				return ErrQueueFull
+			}
+			p.deliver(&DeliveryReport{
+				Stream: stream,
+				Values: values,
+				Err:    ErrQueueFull,
+			})
+		}
+	default:
+		select {
+		case p.queue <- msg:
+		case <-p.closing:
+			return fmt.Errorf("the AsyncProducer has been disposed")
+		}
+	}
+	return nil
+}
+
+// Flush writes the queued messages and waits until they are reported.
+func (p *AsyncProducer) Flush(ctx context.Context) error {
+	done := make(chan struct{})
+	select {
+	case p.flushCh <- done:
+	case <-p.stop:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Close flushes the queued messages and closes the underlying Producer.
+func (p *AsyncProducer) Close() {
+	p.mutex.Lock()
+	if p.disposed {
+		p.mutex.Unlock()
+		return
+	}
+	p.disposed = true
+	close(p.closing)
+	p.mutex.Unlock()
+
+	// wait for the writers queuing the messages
+	p.writers.Wait()
+
+	_ = p.Flush(context.Background())
+
+	close(p.stop)
+	p.wg.Wait()
+
+	if dropped := p.DroppedDeliveries(); dropped > 0 {
+		p.producer.logger.Printf("%d delivery reports are dropped since Deliveries() is full", dropped)
+	}
+	p.producer.Close()
+	if p.deliveries != nil {
+		close(p.deliveries)
+	}
+}
+
+func (p *AsyncProducer) run() {
+	defer p.wg.Done()
+
+	var (
+		batch  = make([]BatchMessage, 0, p.batchSize)
+		timer  = time.NewTimer(p.lingerTime)
+		linger <-chan time.Time
+	)
+	timer.Stop()
+
+	flush := func() {
+		if linger != nil {
+			if !timer.Stop() {
+				select {
+				case <-timer.C:
+				default:
+				}
+			}
+			linger = nil
+		}
+		if len(batch) > 0 {
+			p.send(batch)
+			batch = make([]BatchMessage, 0, p.batchSize)
+		}
+	}
+	add := func(msg *BatchMessage) {
+		batch = append(batch, *msg)
+		if len(batch) >= p.batchSize {
+			flush()
+		} else if linger == nil {
+			timer.Reset(p.lingerTime)
+			linger = timer.C
+		}
+	}
+	drain := func() {
+		for {
+			select {
+			case msg := <-p.queue:
+				add(msg)
+			default:
+				flush()
+				return
+			}
+		}
+	}
+
+	for {
+		select {
+		case msg := <-p.queue:
+			add(msg)
+		case <-linger:
+			linger = nil
+			flush()
+		case done := <-p.flushCh:
+			drain()
+			close(done)
+		case <-p.stop:
+			drain()
+			return
+		}
+	}
+}
+
+func (p *AsyncProducer) send(batch []BatchMessage) {
+	results, err := p.producer.WriteBatchMessages(batch)
+	for i := range batch {
+		report := &DeliveryReport{
+			Stream: batch[i].Stream,
+			Values: batch[i].Values,
+			Err:    err,
+		}
+		if results != nil {
+			report.ID = results[i].ID
+			report.Err = results[i].Err
+		}
+		p.deliver(report)
+	}
+}
+
+func (p *AsyncProducer) deliver(report *DeliveryReport) {
+	if p.deliveryHandler != nil {
+		p.deliveryHandler(report)
+	}
+	if p.deliveries != nil {
+		select {
+		case p.deliveries <- report:
+		default:
+			atomic.AddInt64(&p.dropped, 1)
+		}
+	}
+}
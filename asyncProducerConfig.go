@@ -0,0 +1,14 @@
+package redis
+
+import "time"
+
+type AsyncProducerConfig struct {
+	ProducerConfig
+
+	QueueSize        int                      // 佇列容量, 預設為 DEFAULT_ASYNC_QUEUE_SIZE
+	BatchSize        int                      // 每次 pipeline 的訊息數上限, 預設為 DEFAULT_ASYNC_BATCH_SIZE
+	LingerTime       time.Duration            // 等待湊滿批次的最長時間, 預設為 DEFAULT_ASYNC_LINGER_TIME
+	Backpressure     BackpressurePolicy       // 佇列已滿時的行為, 預設為 BackpressureBlock
+	DeliveryHandler  DeliveryReportHandleProc // 回報每筆訊息的寫入結果
+	ReturnDeliveries bool                     // 若為 true, 寫入結果送至 Deliveries(), 通道已滿時捨棄並計入 DroppedDeliveries()
+}
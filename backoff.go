@@ -0,0 +1,51 @@
+package redis
+
+import (
+	"math"
+	"time"
+)
+
+var (
+	_ BackoffPolicy = BackoffFunc(nil)
+	_ BackoffPolicy = new(ExponentialBackoff)
+)
+
+type BackoffFunc func(attempt int64) time.Duration
+
+// Backoff implements BackoffPolicy.
+func (fn BackoffFunc) Backoff(attempt int64) time.Duration {
+	return fn(attempt)
+}
+
+func ConstantBackoff(interval time.Duration) BackoffFunc {
+	return func(attempt int64) time.Duration {
+		return interval
+	}
+}
+
+type ExponentialBackoff struct {
+	InitialInterval time.Duration
+	MaxInterval     time.Duration
+	Multiplier      float64 // 小於等於 1 時視為 2
+}
+
+// Backoff implements BackoffPolicy.
+func (b *ExponentialBackoff) Backoff(attempt int64) time.Duration {
+	if attempt < 1 {
+		attempt = 1
+	}
+
+	multiplier := b.Multiplier
+	if multiplier <= 1 {
+		multiplier = 2
+	}
+
+	interval := float64(b.InitialInterval) * math.Pow(multiplier, float64(attempt-1))
+	if b.MaxInterval > 0 && interval > float64(b.MaxInterval) {
+		return b.MaxInterval
+	}
+	if interval > math.MaxInt64 {
+		return time.Duration(math.MaxInt64)
+	}
+	return time.Duration(interval)
+}
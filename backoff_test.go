@@ -0,0 +1,64 @@
+package redis
+
+import (
+	"testing"
+	"time"
+)
+
+func TestExponentialBackoff(t *testing.T) {
+	backoff := &ExponentialBackoff{
+		InitialInterval: 100 * time.Millisecond,
+		MaxInterval:     1 * time.Second,
+		Multiplier:      2,
+	}
+
+	expectedAndGot := [][]time.Duration{
+		// run Backoff()       , expected
+		{backoff.Backoff(0), 100 * time.Millisecond},
+		{backoff.Backoff(1), 100 * time.Millisecond},
+		{backoff.Backoff(2), 200 * time.Millisecond},
+		{backoff.Backoff(3), 400 * time.Millisecond},
+		{backoff.Backoff(4), 800 * time.Millisecond},
+		{backoff.Backoff(5), 1 * time.Second},
+		{backoff.Backoff(100), 1 * time.Second},
+	}
+
+	for i, v := range expectedAndGot {
+		expected, got := v[1], v[0]
+		if expected != got {
+			t.Errorf("assert Backoff() at %d :: expected %+v, got %+v", i, expected, got)
+		}
+	}
+}
+
+func TestExponentialBackoff_WithoutMaxInterval(t *testing.T) {
+	backoff := &ExponentialBackoff{
+		InitialInterval: 1 * time.Second,
+	}
+
+	expectedAndGot := [][]time.Duration{
+		// run Backoff()       , expected
+		{backoff.Backoff(1), 1 * time.Second},
+		{backoff.Backoff(2), 2 * time.Second},
+		{backoff.Backoff(3), 4 * time.Second},
+		{backoff.Backoff(1000), time.Duration(1<<63 - 1)},
+	}
+
+	for i, v := range expectedAndGot {
+		expected, got := v[1], v[0]
+		if expected != got {
+			t.Errorf("assert Backoff() at %d :: expected %+v, got %+v", i, expected, got)
+		}
+	}
+}
+
+func TestConstantBackoff(t *testing.T) {
+	backoff := ConstantBackoff(3 * time.Second)
+
+	for _, attempt := range []int64{0, 1, 2, 10} {
+		var expected time.Duration = 3 * time.Second
+		if got := backoff.Backoff(attempt); expected != got {
+			t.Errorf("assert Backoff(%d) :: expected %+v, got %+v", attempt, expected, got)
+		}
+	}
+}
@@ -0,0 +1,7 @@
+package redis
+
+type BatchMessage struct {
+	Stream  string
+	Values  map[string]interface{}
+	Options []ProduceMessageOption // 此訊息專屬的選項, 套用於批次共用的選項之後
+}
@@ -0,0 +1,143 @@
+package redis
+
+import (
+	"sync"
+	"time"
+)
+
+// CircuitBreaker fails the writes fast with ErrCircuitOpen after the
+// consecutive failures, it lets a trial write through after the
+// OpenTimeout and closes again if the trial succeeds.
+type CircuitBreaker struct {
+	FailureThreshold int64                       // 連續失敗達此次數時斷路, 小於 1 時視為 1
+	OpenTimeout      time.Duration               // 斷路後經過此時間允許試探寫入
+	OnStateChange    func(from, to CircuitState) // 狀態改變時呼叫
+
+	mutex    sync.Mutex
+	state    CircuitState
+	failures int64
+	openedAt time.Time
+	trial    bool
+}
+
+func (b *CircuitBreaker) State() CircuitState {
+	if b == nil {
+		return CircuitClosed
+	}
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if b.state == CircuitOpen && time.Since(b.openedAt) >= b.OpenTimeout {
+		return CircuitHalfOpen
+	}
+	return b.state
+}
+
+// allow returns ErrCircuitOpen if the circuit is open, or a trial write is
+// in progress while the circuit is half-open.
+func (b *CircuitBreaker) allow() error {
+	if b == nil {
+		return nil
+	}
+
+	// NOTE: the OnStateChange is called after the mutex is unlocked, so
+	// it can call back the CircuitBreaker.
+	var notify func()
+	defer func() {
+		if notify != nil {
+			notify()
+		}
+	}()
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	switch b.state {
+	case CircuitOpen:
+		if time.Since(b.openedAt) < b.OpenTimeout {
+			return ErrCircuitOpen
+		}
+		notify = b.setState(CircuitHalfOpen)
+		b.trial = true
+	case CircuitHalfOpen:
+		if b.trial {
+			return ErrCircuitOpen
+		}
+		b.trial = true
+	}
+	return nil
+}
+
+func (b *CircuitBreaker) onSuccess() {
+	if b == nil {
+		return
+	}
+
+	var notify func()
+	defer func() {
+		if notify != nil {
+			notify()
+		}
+	}()
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.failures = 0
+	b.trial = false
+	if b.state != CircuitClosed {
+		notify = b.setState(CircuitClosed)
+	}
+}
+
+func (b *CircuitBreaker) onFailure() {
+	if b == nil {
+		return
+	}
+
+	var notify func()
+	defer func() {
+		if notify != nil {
+			notify()
+		}
+	}()
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.failures++
+	b.trial = false
+	if b.state == CircuitHalfOpen ||
+		(b.state == CircuitClosed && b.failures >= b.FailureThreshold) {
+		b.openedAt = time.Now()
+		notify = b.setState(CircuitOpen)
+	}
+}
+
+// release gives up the trial write without changing the state, e.g. the
+// write is canceled by the caller.
+func (b *CircuitBreaker) release() {
+	if b == nil {
+		return
+	}
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.trial = false
+}
+
+// setState changes the state and returns the function calling the
+// OnStateChange, which must be called after the mutex is unlocked.
+func (b *CircuitBreaker) setState(state CircuitState) func() {
+	from := b.state
+	b.state = state
+	if b.OnStateChange == nil {
+		return nil
+	}
+	onStateChange := b.OnStateChange
+	return func() {
+		onStateChange(from, state)
+	}
+}
@@ -0,0 +1,104 @@
+package redis
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	var transitions []CircuitState
+
+	b := &CircuitBreaker{
+		FailureThreshold: 2,
+		OpenTimeout:      50 * time.Millisecond,
+		OnStateChange: func(from, to CircuitState) {
+			transitions = append(transitions, to)
+		},
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := b.allow(); err != nil {
+			t.Fatal(err)
+		}
+		b.onFailure()
+	}
+	if state := b.State(); state != CircuitOpen {
+		t.Errorf("CircuitBreaker.State() expect:: %v, got:: %v\n", CircuitOpen, state)
+	}
+	if err := b.allow(); err != ErrCircuitOpen {
+		t.Errorf("CircuitBreaker.allow() expect:: %v, got:: %v\n", ErrCircuitOpen, err)
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	if state := b.State(); state != CircuitHalfOpen {
+		t.Errorf("CircuitBreaker.State() expect:: %v, got:: %v\n", CircuitHalfOpen, state)
+	}
+
+	// only one trial is allowed while half-open
+	if err := b.allow(); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.allow(); err != ErrCircuitOpen {
+		t.Errorf("CircuitBreaker.allow() expect:: %v, got:: %v\n", ErrCircuitOpen, err)
+	}
+
+	// the failed trial opens the circuit again
+	b.onFailure()
+	if state := b.State(); state != CircuitOpen {
+		t.Errorf("CircuitBreaker.State() expect:: %v, got:: %v\n", CircuitOpen, state)
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	if err := b.allow(); err != nil {
+		t.Fatal(err)
+	}
+	b.onSuccess()
+	if state := b.State(); state != CircuitClosed {
+		t.Errorf("CircuitBreaker.State() expect:: %v, got:: %v\n", CircuitClosed, state)
+	}
+
+	var expectedTransitions = []CircuitState{CircuitOpen, CircuitHalfOpen, CircuitOpen, CircuitHalfOpen, CircuitClosed}
+	if len(expectedTransitions) != len(transitions) {
+		t.Fatalf("CircuitBreaker transitions expect:: %v, got:: %v\n", expectedTransitions, transitions)
+	}
+	for i := range transitions {
+		if expectedTransitions[i] != transitions[i] {
+			t.Errorf("CircuitBreaker transitions expect:: %v, got:: %v\n", expectedTransitions, transitions)
+			break
+		}
+	}
+}
+
+func TestCircuitBreaker_OnStateChangeCallsState(t *testing.T) {
+	var states []CircuitState
+
+	b := &CircuitBreaker{
+		FailureThreshold: 1,
+		OpenTimeout:      time.Minute,
+	}
+	b.OnStateChange = func(from, to CircuitState) {
+		states = append(states, b.State())
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+
+		if err := b.allow(); err != nil {
+			t.Error(err)
+			return
+		}
+		b.onFailure()
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("the OnStateChange calling CircuitBreaker.State() should not deadlock")
+	}
+
+	var expectedStates = []CircuitState{CircuitOpen}
+	if len(expectedStates) != len(states) || expectedStates[0] != states[0] {
+		t.Errorf("CircuitBreaker.State() expect:: %v, got:: %v\n", expectedStates, states)
+	}
+}
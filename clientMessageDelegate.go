@@ -1,6 +1,11 @@
 package redis
 
-var _ MessageDelegate = new(clientMessageDelegate)
+import "time"
+
+var (
+	_ MessageDelegate   = new(clientMessageDelegate)
+	_ ackAndDelDelegate = new(clientMessageDelegate)
+)
 
 type clientMessageDelegate struct {
 	client *Consumer
@@ -23,3 +28,38 @@ func (d *clientMessageDelegate) OnDel(msg *Message) {
 
 	d.client.doDel(msg)
 }
+
+// OnNack implements MessageDelegate.
+func (d *clientMessageDelegate) OnNack(msg *Message, delay time.Duration) {
+	if !msg.canAck() {
+		return
+	}
+
+	d.client.doNack(msg, delay)
+}
+
+// OnRetry implements MessageDelegate.
+func (d *clientMessageDelegate) OnRetry(msg *Message) {
+	if !msg.canAck() {
+		return
+	}
+
+	d.client.doRetry(msg)
+}
+
+// OnAckAndDel implements ackAndDelDelegate.
+func (d *clientMessageDelegate) OnAckAndDel(msg *Message) {
+	var (
+		canAck = msg.canAck()
+		canDel = msg.canDel()
+	)
+
+	switch {
+	case canAck && canDel:
+		d.client.doAckAndDel(msg)
+	case canAck:
+		d.client.doAck(msg)
+	case canDel:
+		d.client.doDel(msg)
+	}
+}
@@ -0,0 +1,100 @@
+package redis
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+
+	"google.golang.org/protobuf/proto"
+)
+
+var (
+	codecRegistry = map[string]Codec{}
+	codecMutex    sync.RWMutex
+)
+
+func init() {
+	RegisterCodec(JSONCodec{})
+	RegisterCodec(MessagePackCodec{})
+	RegisterCodec(ProtobufCodec{})
+}
+
+// RegisterCodec registers the codec by its name, thus the messages
+// encoded by the codec can be decoded by Message.Decode().
+func RegisterCodec(codec Codec) {
+	codecMutex.Lock()
+	defer codecMutex.Unlock()
+
+	codecRegistry[codec.Name()] = codec
+}
+
+// LookupCodec returns the registered codec of the name, or nil if not found.
+func LookupCodec(name string) Codec {
+	codecMutex.RLock()
+	defer codecMutex.RUnlock()
+
+	return codecRegistry[name]
+}
+
+// EncodeMessageContent encodes the v into the payload of the
+// MessageContent, the codec name and the schema of the v are recorded in
+// the MessageState.
+func EncodeMessageContent(codec Codec, v interface{}) (*MessageContent, error) {
+	data, err := codec.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	content := NewMessageContent()
+	content.Values[MESSAGE_PAYLOAD_KEY] = data
+	if _, err := content.State.Set(MESSAGE_STATE_CODEC, codec.Name()); err != nil {
+		return nil, err
+	}
+	if _, err := content.State.Set(MESSAGE_STATE_SCHEMA, schemaOf(v)); err != nil {
+		return nil, err
+	}
+	return content, nil
+}
+
+// Decode decodes the payload into the v by the codec recorded in the
+// MessageState.
+func (c *MessageContent) Decode(v interface{}) error {
+	name, _ := c.State.Value(MESSAGE_STATE_CODEC).(string)
+	if len(name) == 0 {
+		return fmt.Errorf("the message has no codec specified")
+	}
+	codec := LookupCodec(name)
+	if codec == nil {
+		return fmt.Errorf("unknown codec '%s'", name)
+	}
+
+	var data []byte
+	switch payload := c.Values[MESSAGE_PAYLOAD_KEY].(type) {
+	case string:
+		data = []byte(payload)
+	case []byte:
+		data = payload
+	case nil:
+		return fmt.Errorf("the message has no payload")
+	default:
+		return fmt.Errorf("got %T, wanted string as payload", payload)
+	}
+	return codec.Unmarshal(data, v)
+}
+
+// schemaOf returns the full name of the protobuf message, or the Go type
+// name of the v.
+func schemaOf(v interface{}) string {
+	if m, ok := v.(proto.Message); ok {
+		return string(proto.MessageName(m))
+	}
+
+	t := reflect.TypeOf(v)
+	for t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == nil {
+		return ""
+	}
+	return t.String()
+}
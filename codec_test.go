@@ -0,0 +1,92 @@
+package redis
+
+import (
+	"testing"
+
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+type gotestOrder struct {
+	ID     string `json:"id" msgpack:"id"`
+	Amount int    `json:"amount" msgpack:"amount"`
+}
+
+func TestEncodeMessageContent(t *testing.T) {
+	for _, codec := range []Codec{JSONCodec{}, MessagePackCodec{}} {
+		content, err := EncodeMessageContent(codec, &gotestOrder{ID: "order-1", Amount: 100})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var container = make(map[string]interface{})
+		content.WriteTo(container)
+		// NOTE: Redis replies the values as string
+		for k, v := range container {
+			if data, ok := v.([]byte); ok {
+				container[k] = string(data)
+			}
+		}
+
+		decoded := DecodeMessageContent(container)
+		if v := decoded.State.Value(MESSAGE_STATE_CODEC); codec.Name() != v {
+			t.Errorf("MessageState[codec] expect:: %v, got:: %v\n", codec.Name(), v)
+		}
+		var expectedSchema = "redis.gotestOrder"
+		if v := decoded.State.Value(MESSAGE_STATE_SCHEMA); expectedSchema != v {
+			t.Errorf("MessageState[schema] expect:: %v, got:: %v\n", expectedSchema, v)
+		}
+
+		var order gotestOrder
+		err = decoded.Decode(&order)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var expectedOrder = gotestOrder{ID: "order-1", Amount: 100}
+		if expectedOrder != order {
+			t.Errorf("MessageContent.Decode() expect:: %v, got:: %v\n", expectedOrder, order)
+		}
+	}
+}
+
+func TestEncodeMessageContent_WithProtobufCodec(t *testing.T) {
+	content, err := EncodeMessageContent(ProtobufCodec{}, wrapperspb.String("luffy"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var expectedSchema = "google.protobuf.StringValue"
+	if v := content.State.Value(MESSAGE_STATE_SCHEMA); expectedSchema != v {
+		t.Errorf("MessageState[schema] expect:: %v, got:: %v\n", expectedSchema, v)
+	}
+
+	var value wrapperspb.StringValue
+	err = content.Decode(&value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if value.GetValue() != "luffy" {
+		t.Errorf("MessageContent.Decode() expect:: %v, got:: %v\n", "luffy", value.GetValue())
+	}
+
+	_, err = EncodeMessageContent(ProtobufCodec{}, &gotestOrder{})
+	if err == nil {
+		t.Errorf("ProtobufCodec should return error with non proto.Message")
+	}
+}
+
+func TestMessageContent_Decode_WithoutCodec(t *testing.T) {
+	content := NewMessageContent()
+	content.Values[MESSAGE_PAYLOAD_KEY] = "{}"
+
+	var order gotestOrder
+	if err := content.Decode(&order); err == nil {
+		t.Errorf("MessageContent.Decode() should return error without codec")
+	}
+
+	_, err := content.State.Set(MESSAGE_STATE_CODEC, "unknown")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := content.Decode(&order); err == nil {
+		t.Errorf("MessageContent.Decode() should return error with unknown codec")
+	}
+}
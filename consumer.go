@@ -1,31 +1,70 @@
 package redis
 
 import (
+	"context"
 	"fmt"
 	"log"
+	"runtime/debug"
 	"sync"
 	"time"
 
 	redis "github.com/go-redis/redis/v7"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type Consumer struct {
-	Group               string
-	Name                string
-	RedisOption         *redis.UniversalOptions
-	MaxInFlight         int64
-	MaxPollingTimeout   time.Duration
-	ClaimMinIdleTime    time.Duration
-	IdlingTimeout       time.Duration // 若沒有任何訊息時等待多久
-	ClaimSensitivity    int           // Read 時取得的訊息數小於 n 的話, 執行 Claim
-	ClaimOccurrenceRate int32         // Read 每執行 n 次後 執行 Claim 1 次
-	MessageHandler      MessageHandleProc
-	ErrorHandler        ErrorHandleProc
-	Logger              *log.Logger
-
-	client   *consumerClient
-	stopChan chan bool
-	wg       sync.WaitGroup
+	Group                 string
+	Name                  string
+	RedisOption           *redis.UniversalOptions
+	MaxInFlight           int64
+	MaxPollingTimeout     time.Duration
+	ClaimMinIdleTime      time.Duration
+	IdlingTimeout         time.Duration // 若沒有任何訊息時等待多久
+	ClaimSensitivity      int           // Read 時取得的訊息數小於 n 的話, 執行 Claim
+	ClaimOccurrenceRate   int32         // Read 每執行 n 次後 執行 Claim 1 次
+	Concurrency           int           // 同時處理訊息的 worker 數量, 小於等於 0 時於 polling goroutine 中依序處理
+	MaxDeliveryCount      int64         // 訊息遞送次數達 n 次後移至 DeadLetterStream, 0 表示不限制
+	DeadLetterStream      string        // 若未設定, 超過 MaxDeliveryCount 的訊息將直接 XACK
+	AutoCreateGroup       bool          // 自動建立不存在的 consumer group 及 stream, 執行中 group 被刪除時亦會重建
+	GroupStartOffset      string        // 自動建立 consumer group 時的起始 ID, 預設為 StreamLastDeliveredID
+	DiscoveryInterval     time.Duration // 以 StreamPattern 訂閱時重新掃描 stream 的間隔
+	RetryBackoff          BackoffPolicy // Message.Retry() 重新遞送的延遲, 延遲超過 ClaimMinIdleTime 時訊息可能被其他 consumer claim
+	BatchSize             int           // BatchMessageHandler 每批訊息的數量上限, 小於等於 0 時不限制
+	BatchWindow           time.Duration // BatchMessageHandler 每批訊息最長等待多久送出, 小於等於 0 時每次 polling 後送出
+	MessageHandler        MessageHandleProc
+	ContextMessageHandler ContextMessageHandleProc // 若有設定, 優先於 MessageHandler 使用
+	BatchMessageHandler   BatchMessageHandleProc   // 若有設定, 優先於 MessageHandler 及 ContextMessageHandler 使用, 不受 Concurrency 影響
+	AckBatchSize          int                      // 累積 n 個 XACK/XDEL 後以 pipeline 送出, 小於等於 1 時立即送出
+	AckFlushInterval      time.Duration            // 累積的 XACK/XDEL 最長等待多久送出
+	ErrorHandler          ErrorHandleProc
+	ErrorPolicy           ErrorPolicy   // ErrorHandler 未處理的錯誤的處理方式, 預設為 ErrorPolicyStop
+	ErrorBackoff          BackoffPolicy // ErrorPolicyRetry 時重試的延遲
+	Logger                *log.Logger
+
+	Tracer                trace.Tracer                  // 若有設定, 處理訊息時建立 consumer span
+	TextMapPropagator     propagation.TextMapPropagator // 自 MessageState 取出 trace context, 預設為 trace.GetTextMapPropagator()
+	MessageStateKeyPrefix string                        // 取出 trace context 時 MessageState 的 key prefix, 預設為 "header:"
+	MeterProvider         metric.MeterProvider          // 預設為 otel.GetMeterProvider()
+
+	client         *consumerClient
+	metrics        *consumerMetrics
+	workerPool     *messageWorkerPool
+	retryScheduler *retryScheduler
+	ackBuffer      *ackBuffer
+	stopChan       chan bool
+	wg             sync.WaitGroup
+
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	batch          []*Message
+	batchStartedAt time.Time
+
+	done     chan struct{}
+	err      error
+	errMutex sync.Mutex
 
 	claimTrigger *CyclicCounter
 
@@ -36,6 +75,10 @@ type Consumer struct {
 }
 
 func (c *Consumer) Subscribe(streams ...StreamOffsetInfo) error {
+	return c.SubscribeContext(context.Background(), streams...)
+}
+
+func (c *Consumer) SubscribeContext(ctx context.Context, streams ...StreamOffsetInfo) error {
 	if c.disposed {
 		return fmt.Errorf("the Consumer has been disposed")
 	}
@@ -59,12 +102,23 @@ func (c *Consumer) Subscribe(streams ...StreamOffsetInfo) error {
 	c.init()
 	c.running = true
 
+	c.metrics, err = newConsumerMetrics(c.MeterProvider, c.Group)
+	if err != nil {
+		return err
+	}
+
 	// new consumer
 	{
 		consumer := &consumerClient{
-			Group:       c.Group,
-			Name:        c.Name,
-			RedisOption: c.RedisOption,
+			Group:            c.Group,
+			Name:             c.Name,
+			RedisOption:      c.RedisOption,
+			MaxDeliveryCount: c.MaxDeliveryCount,
+			DeadLetterStream: c.DeadLetterStream,
+			AutoCreateGroup:  c.AutoCreateGroup,
+			GroupStartOffset: c.GroupStartOffset,
+			retryScheduler:   c.retryScheduler,
+			metrics:          c.metrics,
 		}
 
 		err = consumer.subscribe(streams...)
@@ -77,25 +131,107 @@ func (c *Consumer) Subscribe(streams ...StreamOffsetInfo) error {
 
 	// reset
 	c.claimTrigger.reset()
+	c.ctx, c.cancel = context.WithCancel(ctx)
+
+	// start workers
+	if c.Concurrency > 0 && c.BatchMessageHandler == nil {
+		c.workerPool = newMessageWorkerPool(c.Concurrency, c.MaxInFlight, c.processHandler)
+		c.workerPool.start()
+	}
+
+	// start ack flusher
+	var (
+		ackFlusherStop chan struct{}
+		ackFlusherDone <-chan struct{}
+	)
+	if c.AckBatchSize > 1 {
+		c.ackBuffer = newAckBuffer(c.AckBatchSize)
+
+		ackFlusherStop = make(chan struct{})
+		ackFlusherDone = c.startAckFlusher(ackFlusherStop)
+	}
+
+	// start stream discoverer
+	var (
+		discovererStop = make(chan struct{})
+		discovererDone = c.startStreamDiscoverer(discovererStop)
+	)
+
+	c.setErr(nil)
+	c.done = make(chan struct{})
 
 	c.wg.Add(1)
 	go func() {
 		defer c.wg.Done()
+		defer close(c.done)
 		defer c.client.close()
+		defer func() {
+			close(discovererStop)
+			<-discovererDone
+		}()
+		defer func() {
+			if ackFlusherStop != nil {
+				close(ackFlusherStop)
+				<-ackFlusherDone
+			}
+			// flush the buffered XACK/XDEL before the client closed
+			c.flushAck()
+		}()
+		defer func() {
+			// wait for in-flight messages before the client closed
+			if c.workerPool != nil {
+				c.workerPool.close()
+			}
+		}()
+		defer c.flushBatch()
 
+		var retryAttempt int64 = 0
 		for {
 			select {
 			case <-c.stopChan:
 				return
 
+			case <-c.ctx.Done():
+				c.setErr(ctx.Err())
+				return
+
 			default:
-				err := c.processMessage()
-				if err != nil {
-					if !c.processError(err) {
-						c.Logger.Fatalf("%% Error: %v\n", err)
-						return
+				err := c.processMessage(c.ctx)
+				c.flushBatchIfDue()
+				if err == nil {
+					retryAttempt = 0
+					continue
+				}
+				if c.ctx.Err() != nil {
+					c.setErr(ctx.Err())
+					return
+				}
+				if c.processError(err) {
+					continue
+				}
+
+				switch c.ErrorPolicy {
+				case ErrorPolicyRetry:
+					if isTransientError(err) {
+						retryAttempt++
+						delay := c.ErrorBackoff.Backoff(retryAttempt)
+						c.Logger.Printf("%% Error: %v, retry after %v\n", err, delay)
+
+						select {
+						case <-time.After(delay):
+						case <-c.ctx.Done():
+						case <-c.stopChan:
+							return
+						}
+						continue
 					}
+				case ErrorPolicyExit:
+					c.Logger.Fatalf("%% Error: %v\n", err)
 				}
+
+				c.Logger.Printf("%% Error: %v\n", err)
+				c.setErr(err)
+				return
 			}
 		}
 	}()
@@ -103,10 +239,18 @@ func (c *Consumer) Subscribe(streams ...StreamOffsetInfo) error {
 }
 
 func (c *Consumer) Close() {
+	c.CloseContext(context.Background())
+}
+
+// CloseContext stops polling and waits for the in-flight messages. If ctx is
+// done before the handlers return, the context passed to the handlers will be
+// canceled and ctx.Err() is returned.
+func (c *Consumer) CloseContext(ctx context.Context) error {
 	if c.disposed {
-		return
+		return nil
 	}
 
+	var err error
 	c.mutex.Lock()
 	defer func() {
 		c.running = false
@@ -120,7 +264,73 @@ func (c *Consumer) Close() {
 		close(c.stopChan)
 	}
 
-	c.wg.Wait()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		c.wg.Wait()
+	}()
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	if c.cancel != nil {
+		c.cancel()
+	}
+	<-done
+	return err
+}
+
+// Done returns a channel which is closed when the Consumer stops polling,
+// either it is closed or an error occurred.
+func (c *Consumer) Done() <-chan struct{} {
+	return c.done
+}
+
+// Err returns the error which stopped the Consumer. It returns nil if
+// the Consumer is running or closed by Close().
+func (c *Consumer) Err() error {
+	c.errMutex.Lock()
+	defer c.errMutex.Unlock()
+
+	return c.err
+}
+
+// AckBatch acknowledges the messages through a pipeline.
+func (c *Consumer) AckBatch(msgs ...*Message) error {
+	if c.disposed {
+		return fmt.Errorf("the Consumer has been disposed")
+	}
+	if !c.running {
+		return fmt.Errorf("the Consumer is not running")
+	}
+
+	var acks = make(map[string][]string)
+	for _, m := range msgs {
+		if m.canAck() {
+			acks[m.Stream] = append(acks[m.Stream], m.ID)
+		}
+	}
+	return c.client.ackBatch(acks, nil)
+}
+
+// AddStreams subscribes the streams while the Consumer is running.
+func (c *Consumer) AddStreams(streams ...StreamOffsetInfo) error {
+	if c.client == nil {
+		return fmt.Errorf("the Consumer is not running")
+	}
+	return c.client.addStreams(streams...)
+}
+
+// RemoveStreams unsubscribes the streams while the Consumer is running.
+// The pending messages of the streams are left in the consumer group.
+func (c *Consumer) RemoveStreams(streams ...string) error {
+	if c.client == nil {
+		return fmt.Errorf("the Consumer is not running")
+	}
+	return c.client.removeStreams(streams...)
 }
 
 func (c *Consumer) Pause(streams ...string) error {
@@ -144,30 +354,80 @@ func (c *Consumer) init() {
 		c.claimTrigger = newCyclicCounter(c.ClaimOccurrenceRate)
 	}
 
+	if c.retryScheduler == nil {
+		c.retryScheduler = newRetryScheduler()
+	}
+
+	if c.RetryBackoff == nil {
+		c.RetryBackoff = defaultRetryBackoff
+	}
+
+	if c.ErrorBackoff == nil {
+		c.ErrorBackoff = defaultErrorBackoff
+	}
+
 	if c.Logger == nil {
 		c.Logger = defaultLogger
 	}
 	c.initialized = true
 }
 
+func (c *Consumer) setErr(err error) {
+	c.errMutex.Lock()
+	defer c.errMutex.Unlock()
+
+	c.err = err
+}
+
 func (c *Consumer) processError(err error) (disposed bool) {
+	c.metrics.addError(err)
+
 	if c.ErrorHandler != nil {
-		consumerErr := &ConsumerError{
-			err: err,
+		consumerErr, ok := err.(*ConsumerError)
+		if !ok {
+			consumerErr = &ConsumerError{
+				err: err,
+			}
 		}
 		return c.ErrorHandler(consumerErr)
 	}
 	return false
 }
 
-func (c *Consumer) processMessage() error {
+func (c *Consumer) processMessage(ctx context.Context) error {
 	var (
-		readMessages int = 0
+		readMessages int   = 0
+		available    int64 = c.computeAvailableInFlight()
 	)
 
+	// wait until any in-flight message has been released
+	if c.MaxInFlight > 0 && available <= 0 {
+		if len(c.batch) > 0 {
+			c.flushBatch()
+			return nil
+		}
+		c.workerPool.wait(ctx, c.stopChan)
+		return nil
+	}
+
+	// redeliver the messages which are due to retry
+	{
+		retriedMessages, err := c.processRetry(available)
+		if err != nil {
+			return err
+		}
+
+		if c.MaxInFlight > 0 && retriedMessages > 0 {
+			available -= int64(retriedMessages)
+			if available <= 0 {
+				return nil
+			}
+		}
+	}
+
 	// perform XREADGROUP
 	{
-		streams, err := c.client.read(c.MaxInFlight, c.MaxPollingTimeout)
+		streams, err := c.client.readContext(ctx, available, c.computePollingTimeout())
 		if err != nil {
 			if err != redis.Nil {
 				return err
@@ -176,8 +436,8 @@ func (c *Consumer) processMessage() error {
 
 		if len(streams) > 0 {
 			for _, stream := range streams {
-				for _, message := range stream.Messages {
-					c.handleMessage(stream.Stream, &message)
+				for i := range stream.Messages {
+					c.handleMessage(stream.Stream, &stream.Messages[i])
 					readMessages++
 				}
 			}
@@ -191,7 +451,14 @@ func (c *Consumer) processMessage() error {
 			pendingFetchingSize = c.computePendingFetchingSize(c.MaxInFlight)
 		)
 
-		streams, err := c.client.claim(c.ClaimMinIdleTime, c.MaxInFlight, pendingFetchingSize)
+		if c.MaxInFlight > 0 {
+			available -= int64(readMessages)
+			if available <= 0 {
+				return nil
+			}
+		}
+
+		streams, err := c.client.claim(c.ClaimMinIdleTime, available, pendingFetchingSize)
 		if err != nil {
 			if err != redis.Nil {
 				return err
@@ -199,20 +466,88 @@ func (c *Consumer) processMessage() error {
 		}
 		if len(streams) > 0 {
 			for _, stream := range streams {
-				for _, message := range stream.Messages {
-					c.handleMessage(stream.Stream, &message)
+				for i := range stream.Messages {
+					c.handleMessage(stream.Stream, &stream.Messages[i])
 				}
 			}
 			return nil
 		}
 
 		if readMessages == 0 {
-			time.Sleep(c.IdlingTimeout)
+			select {
+			case <-time.After(c.computeIdlingTimeout()):
+			case <-ctx.Done():
+			}
 		}
 	}
 	return nil
 }
 
+func (c *Consumer) processRetry(limit int64) (int, error) {
+	var (
+		due          = c.retryScheduler.due(time.Now(), int(limit))
+		messageCount = 0
+	)
+
+	for stream, ids := range due {
+		messages, err := c.client.redeliver(stream, ids...)
+		if err != nil {
+			return messageCount, err
+		}
+
+		for i := range messages {
+			c.handleMessage(stream, &messages[i])
+			messageCount++
+		}
+	}
+	return messageCount, nil
+}
+
+func (c *Consumer) computeAvailableInFlight() int64 {
+	if c.MaxInFlight <= 0 {
+		return c.MaxInFlight
+	}
+
+	available := c.MaxInFlight - int64(len(c.batch))
+	if c.workerPool != nil {
+		available -= c.workerPool.countInFlight()
+	}
+	return available
+}
+
+func (c *Consumer) computeIdlingTimeout() time.Duration {
+	if len(c.batch) == 0 || c.BatchWindow <= 0 {
+		return c.IdlingTimeout
+	}
+
+	// don't hold the accumulated messages over the BatchWindow
+	remaining := c.BatchWindow - time.Since(c.batchStartedAt)
+	if remaining < 0 {
+		return 0
+	}
+	if remaining < c.IdlingTimeout {
+		return remaining
+	}
+	return c.IdlingTimeout
+}
+
+func (c *Consumer) computePollingTimeout() time.Duration {
+	if len(c.batch) == 0 || c.BatchWindow <= 0 || c.MaxPollingTimeout < 0 {
+		return c.MaxPollingTimeout
+	}
+
+	// don't block XREADGROUP over the BatchWindow of the accumulated messages
+	remaining := c.BatchWindow - time.Since(c.batchStartedAt)
+	if remaining < time.Millisecond {
+		// NOTE: BLOCK 0 blocks forever, read without blocking instead
+		return -1
+	}
+	if c.MaxPollingTimeout == 0 || remaining < c.MaxPollingTimeout {
+		return remaining
+	}
+	return c.MaxPollingTimeout
+}
+
 func (c *Consumer) computePendingFetchingSize(maxInFlight int64) int64 {
 	var (
 		fetchingSize = maxInFlight * PENDING_FETCHING_SIZE_COEFFICIENT
@@ -228,7 +563,9 @@ func (c *Consumer) computePendingFetchingSize(maxInFlight int64) int64 {
 }
 
 func (c *Consumer) handleMessage(stream string, m *redis.XMessage) {
-	if c.MessageHandler == nil {
+	if c.MessageHandler == nil &&
+		c.ContextMessageHandler == nil &&
+		c.BatchMessageHandler == nil {
 		return
 	}
 
@@ -237,8 +574,109 @@ func (c *Consumer) handleMessage(stream string, m *redis.XMessage) {
 		ConsumerGroup: c.Group,
 		Stream:        stream,
 		Delegate:      &clientMessageDelegate{client: c},
+		ctx:           c.ctx,
+	}
+	if c.Tracer != nil || c.TextMapPropagator != nil {
+		msg.ctx = extractMessageTraceContext(msg, c.TextMapPropagator, c.MessageStateKeyPrefix)
+	}
+	c.metrics.addConsumed(stream, 1)
+
+	if c.BatchMessageHandler != nil {
+		c.appendBatch(msg)
+		return
+	}
+	if c.workerPool != nil {
+		c.workerPool.dispatch(msg)
+		return
+	}
+	c.processHandler(msg)
+}
+
+func (c *Consumer) appendBatch(msg *Message) {
+	if len(c.batch) == 0 {
+		c.batchStartedAt = time.Now()
 	}
+	c.batch = append(c.batch, msg)
 
+	if c.BatchSize > 0 && len(c.batch) >= c.BatchSize {
+		c.flushBatch()
+	}
+}
+
+func (c *Consumer) flushBatchIfDue() {
+	if len(c.batch) == 0 {
+		return
+	}
+	if c.BatchWindow > 0 && time.Since(c.batchStartedAt) < c.BatchWindow {
+		return
+	}
+	c.flushBatch()
+}
+
+func (c *Consumer) flushBatch() {
+	if len(c.batch) == 0 {
+		return
+	}
+
+	batch := c.batch
+	c.batch = nil
+	c.processBatchHandler(batch)
+}
+
+func (c *Consumer) processBatchHandler(msgs []*Message) {
+	var (
+		spans  = make([]trace.Span, len(msgs))
+		counts = make(map[string]int64)
+	)
+	for i, msg := range msgs {
+		spans[i] = startMessageSpan(c.Tracer, msg)
+		counts[msg.Stream]++
+	}
+	for stream, n := range counts {
+		defer c.metrics.startHandling(stream, n)()
+	}
+
+	// NOTE: the messages are left pending when the handler panics, and
+	// they will be redelivered by the claim process.
+	defer func() {
+		if r := recover(); r != nil {
+			err := newPanicConsumerError(r, debug.Stack(), nil)
+			for _, span := range spans {
+				recordSpanError(span, err)
+			}
+			if !c.processError(err) {
+				c.Logger.Printf("%% Error: %v\n%s", err, err.Stack())
+			}
+		}
+		for _, span := range spans {
+			span.End()
+		}
+	}()
+
+	c.BatchMessageHandler(msgs)
+}
+
+func (c *Consumer) processHandler(msg *Message) {
+	span := startMessageSpan(c.Tracer, msg)
+	defer c.metrics.startHandling(msg.Stream, 1)()
+
+	// NOTE: the message is left pending when the handler panics, and it
+	// will be redelivered by the claim process.
+	defer func() {
+		if r := recover(); r != nil {
+			err := newPanicConsumerError(r, debug.Stack(), msg)
+			recordSpanError(span, err)
+			if !c.processError(err) {
+				c.Logger.Printf("%% Error: %v\n%s", err, err.Stack())
+			}
+		}
+		span.End()
+	}()
+
+	if c.ContextMessageHandler != nil {
+		c.ContextMessageHandler(msg.Context(), msg)
+		return
+	}
 	c.MessageHandler(msg)
 }
 
@@ -250,6 +688,13 @@ func (c *Consumer) doAck(m *Message) {
 		return
 	}
 
+	if c.ackBuffer != nil {
+		if c.ackBuffer.ack(m.Stream, m.ID) {
+			c.flushAck()
+		}
+		return
+	}
+
 	_, err := c.client.ack(m.Stream, m.ID)
 	if err != nil {
 		c.Logger.Printf("error sending command XACK '%s' '%s' '%s'", m.Stream, c.Group, m.ID)
@@ -264,8 +709,166 @@ func (c *Consumer) doDel(m *Message) {
 		return
 	}
 
+	if c.ackBuffer != nil {
+		if c.ackBuffer.del(m.Stream, m.ID) {
+			c.flushAck()
+		}
+		return
+	}
+
 	_, err := c.client.del(m.Stream, m.ID)
 	if err != nil {
 		c.Logger.Printf("error sending command XACK '%s' '%s'", m.Stream, m.ID)
 	}
 }
+
+func (c *Consumer) doAckAndDel(m *Message) {
+	if c.disposed {
+		return
+	}
+	if !c.running {
+		return
+	}
+
+	err := c.client.ackAndDel(m.Stream, m.ID)
+	if err != nil {
+		c.Logger.Printf("error sending command XACK '%s' '%s' '%s' and XDEL '%s' '%s'", m.Stream, c.Group, m.ID, m.Stream, m.ID)
+	}
+}
+
+func (c *Consumer) flushAck() {
+	if c.ackBuffer == nil {
+		return
+	}
+
+	acks, dels := c.ackBuffer.drain()
+	err := c.client.ackBatch(acks, dels)
+	if err != nil {
+		c.Logger.Printf("error sending pipelined XACK/XDEL: %v", err)
+	}
+}
+
+func (c *Consumer) startAckFlusher(stop <-chan struct{}) <-chan struct{} {
+	var (
+		interval = c.AckFlushInterval
+		done     = make(chan struct{})
+	)
+	if interval <= 0 {
+		interval = DEFAULT_ACK_FLUSH_INTERVAL
+	}
+
+	go func() {
+		defer close(done)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				c.flushAck()
+			}
+		}
+	}()
+	return done
+}
+
+// startStreamDiscoverer refreshes the streams subscribed by StreamPattern
+// periodically.
+func (c *Consumer) startStreamDiscoverer(stop <-chan struct{}) <-chan struct{} {
+	var (
+		interval = c.DiscoveryInterval
+		done     = make(chan struct{})
+	)
+	if interval <= 0 {
+		interval = DEFAULT_STREAM_DISCOVERY_INTERVAL
+	}
+
+	go func() {
+		defer close(done)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				if !c.client.hasStreamPatterns() {
+					continue
+				}
+				if err := c.client.refreshStreams(); err != nil {
+					if !c.processError(err) {
+						c.Logger.Printf("%% Error: %v\n", err)
+					}
+				}
+			}
+		}
+	}()
+	return done
+}
+
+func (c *Consumer) doNack(m *Message, delay time.Duration) {
+	if c.disposed {
+		return
+	}
+	if !c.running {
+		return
+	}
+
+	if c.MaxDeliveryCount > 0 {
+		attempt, err := c.client.deliveryCount(m.Stream, m.ID)
+		if err != nil {
+			c.Logger.Printf("error sending command XPENDING '%s' '%s' '%s' '%s' 1", m.Stream, c.Group, m.ID, m.ID)
+		}
+		if c.deadLetterIfExceeded(m, attempt) {
+			return
+		}
+	}
+	c.scheduleRetry(m, delay)
+}
+
+func (c *Consumer) doRetry(m *Message) {
+	if c.disposed {
+		return
+	}
+	if !c.running {
+		return
+	}
+
+	attempt, err := c.client.deliveryCount(m.Stream, m.ID)
+	if err != nil {
+		c.Logger.Printf("error sending command XPENDING '%s' '%s' '%s' '%s' 1", m.Stream, c.Group, m.ID, m.ID)
+	}
+	if c.deadLetterIfExceeded(m, attempt) {
+		return
+	}
+	c.scheduleRetry(m, c.RetryBackoff.Backoff(attempt))
+}
+
+func (c *Consumer) scheduleRetry(m *Message, delay time.Duration) {
+	// prevent the message being claimed before it is due
+	err := c.client.touch(m.Stream, m.ID)
+	if err != nil {
+		c.Logger.Printf("error sending command XCLAIM '%s' '%s' '%s' IDLE 0", m.Stream, c.Group, m.ID)
+	}
+	c.retryScheduler.schedule(m.Stream, m.ID, time.Now().Add(delay))
+}
+
+// deadLetterIfExceeded moves the message to the DeadLetterStream if it has
+// been delivered MaxDeliveryCount times, and reports whether it is moved.
+func (c *Consumer) deadLetterIfExceeded(m *Message, attempt int64) bool {
+	if c.MaxDeliveryCount <= 0 || attempt < c.MaxDeliveryCount {
+		return false
+	}
+
+	reason := fmt.Sprintf("exceeded max delivery count %d", c.MaxDeliveryCount)
+	err := c.client.deadLetter(m.Stream, 0, reason, m.ID)
+	if err != nil {
+		c.Logger.Printf("error moving message '%s' '%s' to dead-letter stream '%s'", m.Stream, m.ID, c.DeadLetterStream)
+	}
+	return true
+}
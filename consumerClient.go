@@ -1,6 +1,7 @@
 package redis
 
 import (
+	"context"
 	"fmt"
 	"sync"
 	"time"
@@ -9,17 +10,33 @@ import (
 )
 
 type consumerClient struct {
-	Group       string
-	Name        string
-	RedisOption *redis.UniversalOptions
+	Group            string
+	Name             string
+	RedisOption      *redis.UniversalOptions
+	MaxDeliveryCount int64
+	DeadLetterStream string
+	AutoCreateGroup  bool
+	GroupStartOffset string
 
-	client UniversalClient
-	wg     sync.WaitGroup
+	client         UniversalClient
+	readClient     UniversalClient // the client of XREADGROUP, closed to interrupt the blocking read
+	readMutex      sync.Mutex
+	retryScheduler *retryScheduler
+	metrics        *consumerMetrics
+	serverVersion  redisServerVersion
+	wg             sync.WaitGroup
+
+	autoClaimCursors map[string]string
 
 	streams          []StreamOffsetInfo
 	streamKeyState   *sync.Map
 	streamKeys       []string
 	streamKeyOffsets []string
+	streamMutex      sync.RWMutex // guards the streams, streamKeys, streamKeyOffsets, streamPatterns and autoClaimCursors
+
+	streamPatterns    []StreamPattern
+	discoveredStreams *sync.Map  // stream -> pattern
+	discoveryMutex    sync.Mutex // serializes the discovery and the group recovery
 
 	mutex    sync.Mutex
 	running  bool
@@ -37,6 +54,8 @@ func (c *consumerClient) subscribe(streams ...StreamOffsetInfo) error {
 		return fmt.Errorf("the Consumer is running")
 	}
 
+	streams, patterns := splitStreamPatterns(streams)
+
 	var (
 		size     = len(streams)
 		keyState = new(sync.Map)
@@ -58,6 +77,7 @@ func (c *consumerClient) subscribe(streams ...StreamOffsetInfo) error {
 	if err = c.configRedisClient(); err != nil {
 		return err
 	}
+	c.detectServerVersion()
 
 	if size > 0 {
 		for i := 0; i < size; i++ {
@@ -67,11 +87,26 @@ func (c *consumerClient) subscribe(streams ...StreamOffsetInfo) error {
 			keys = append(keys, k)
 		}
 	}
+	if c.AutoCreateGroup {
+		if err = c.createGroup(keys...); err != nil {
+			return err
+		}
+	}
+	c.streamMutex.Lock()
 	c.streams = streams
 	c.streamKeys = keys
 	c.streamKeyState = keyState
+	c.streamPatterns = patterns
+	c.discoveredStreams = new(sync.Map)
+	c.autoClaimCursors = make(map[string]string, size)
 	c.updateStreamKeyOffset()
+	c.streamMutex.Unlock()
 
+	if len(patterns) > 0 {
+		if err = c.discoverStreams(patterns...); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
@@ -86,12 +121,39 @@ func (c *consumerClient) claim(minIdleTime time.Duration, count int64, pendingFe
 	c.wg.Add(1)
 	defer c.wg.Done()
 
-	var resultStream []redis.XStream = make([]redis.XStream, 0, len(c.streamKeys))
-	for _, stream := range c.streamKeys {
+	var (
+		streamKeys   = c.getStreamKeys()
+		resultStream = make([]redis.XStream, 0, len(streamKeys))
+	)
+	for _, stream := range streamKeys {
 		if !c.isConnected(stream) {
 			continue
 		}
 
+		// NOTE: XAUTOCLAIM increases the delivery counters of the messages
+		// waiting for retry before they can be skipped, use XPENDING to
+		// skip them before claiming instead.
+		if c.canAutoClaim() && !c.hasRetryScheduled(stream) {
+			claimMessages, err := c.autoClaim(stream, minIdleTime, count)
+			if err != nil {
+				if c.canRecoverGroup(err) {
+					if err := c.recoverGroup(err, stream); err != nil {
+						return nil, err
+					}
+					continue
+				}
+				return nil, err
+			}
+			if len(claimMessages) > 0 {
+				resultStream = append(resultStream, redis.XStream{
+					Stream:   stream,
+					Messages: claimMessages,
+				})
+				c.metrics.addClaimed(stream, int64(len(claimMessages)))
+			}
+			continue
+		}
+
 		// fetch all pending messages from specified redis stream key
 		pendingSet, err := c.client.XPendingExt(&redis.XPendingExtArgs{
 			Stream: stream,
@@ -101,6 +163,12 @@ func (c *consumerClient) claim(minIdleTime time.Duration, count int64, pendingFe
 			Count:  pendingFetchingSize,
 		}).Result()
 		if err != nil {
+			if c.canRecoverGroup(err) {
+				if err := c.recoverGroup(err, stream); err != nil {
+					return nil, err
+				}
+				continue
+			}
 			if err != redis.Nil {
 				return nil, err
 			}
@@ -109,6 +177,7 @@ func (c *consumerClient) claim(minIdleTime time.Duration, count int64, pendingFe
 		if len(pendingSet) > 0 {
 			var (
 				pendingMessageIDs []string = make([]string, 0, count)
+				deadLetterIDs     []string
 			)
 
 			// filter the message ids that only the idle time over
@@ -116,6 +185,17 @@ func (c *consumerClient) claim(minIdleTime time.Duration, count int64, pendingFe
 			for _, pending := range pendingSet {
 				// update the last pending id
 				if pending.Idle >= minIdleTime {
+					// skip the message which is waiting for retry
+					if c.retryScheduler != nil && c.retryScheduler.contains(stream, pending.ID) {
+						continue
+					}
+
+					// the message has been delivered too many times
+					if c.MaxDeliveryCount > 0 && pending.RetryCount >= c.MaxDeliveryCount {
+						deadLetterIDs = append(deadLetterIDs, pending.ID)
+						continue
+					}
+
 					pendingMessageIDs = append(pendingMessageIDs, pending.ID)
 
 					if len(pendingMessageIDs) == int(count) {
@@ -124,6 +204,13 @@ func (c *consumerClient) claim(minIdleTime time.Duration, count int64, pendingFe
 				}
 			}
 
+			if len(deadLetterIDs) > 0 {
+				reason := fmt.Sprintf("exceeded max delivery count %d", c.MaxDeliveryCount)
+				if err := c.deadLetter(stream, minIdleTime, reason, deadLetterIDs...); err != nil {
+					return nil, err
+				}
+			}
+
 			if len(pendingMessageIDs) > 0 {
 				claimMessages, err := c.client.XClaim(&redis.XClaimArgs{
 					Stream:   stream,
@@ -185,6 +272,7 @@ func (c *consumerClient) claim(minIdleTime time.Duration, count int64, pendingFe
 						Stream:   stream,
 						Messages: claimMessages,
 					})
+					c.metrics.addClaimed(stream, int64(len(claimMessages)))
 				}
 			}
 		}
@@ -193,34 +281,83 @@ func (c *consumerClient) claim(minIdleTime time.Duration, count int64, pendingFe
 }
 
 func (c *consumerClient) read(count int64, timeout time.Duration) ([]redis.XStream, error) {
+	return c.readContext(context.Background(), count, timeout)
+}
+
+func (c *consumerClient) readContext(ctx context.Context, count int64, timeout time.Duration) ([]redis.XStream, error) {
 	if c.disposed {
 		return nil, fmt.Errorf("the Consumer has been disposed")
 	}
 	if !c.running {
 		return nil, fmt.Errorf("the Consumer is not running")
 	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
 
 	// return nil if unset stream offset
-	if len(c.streamKeyOffsets) == 0 {
+	streamKeyOffsets := c.getStreamKeyOffsets()
+	if len(streamKeyOffsets) == 0 {
 		return nil, nil
 	}
 
 	c.wg.Add(1)
-	defer c.wg.Done()
 
-	messages, err := c.client.XReadGroup(&redis.XReadGroupArgs{
-		Group:    c.Group,
-		Consumer: c.Name,
-		Count:    count,
-		Streams:  c.streamKeyOffsets,
-		Block:    timeout,
-	}).Result()
+	type readReply struct {
+		messages []redis.XStream
+		err      error
+	}
+
+	var (
+		args = &redis.XReadGroupArgs{
+			Group:    c.Group,
+			Consumer: c.Name,
+			Count:    count,
+			Streams:  streamKeyOffsets,
+			Block:    timeout,
+		}
+		replyChan = make(chan readReply, 1)
+		start     = time.Now()
+	)
+	client, err := c.acquireReadClient()
 	if err != nil {
-		if err != redis.Nil {
-			return nil, err
+		c.wg.Done()
+		return nil, err
+	}
+	go func() {
+		defer c.wg.Done()
+
+		messages, err := withContext(client, ctx).XReadGroup(args).Result()
+		replyChan <- readReply{messages, err}
+	}()
+
+	var reply readReply
+	select {
+	case reply = <-replyChan:
+	case <-ctx.Done():
+		// NOTE: go-redis doesn't interrupt the blocking command on the
+		// cancellation, close the connections instead. The messages read
+		// by the abandoned XREADGROUP are still pending in the group and
+		// will be claimed later.
+		c.interruptRead(client)
+		return nil, ctx.Err()
+	}
+
+	if reply.err != nil {
+		if c.canRecoverGroup(reply.err) {
+			// NOTE: XREADGROUP doesn't tell which stream is missing the
+			// group, recreate all of them.
+			if err := c.recoverGroup(reply.err, c.getStreamKeys()...); err != nil {
+				return nil, err
+			}
+			return nil, nil
+		}
+		if reply.err != redis.Nil {
+			return nil, reply.err
 		}
 	}
-	return messages, nil
+	c.metrics.recordRead(start, reply.messages)
+	return reply.messages, nil
 }
 
 func (c *consumerClient) ack(key string, id ...string) (int64, error) {
@@ -240,6 +377,7 @@ func (c *consumerClient) ack(key string, id ...string) (int64, error) {
 			return 0, err
 		}
 	}
+	c.metrics.addAcked(key, reply)
 	return reply, nil
 }
 
@@ -260,10 +398,83 @@ func (c *consumerClient) del(key string, id ...string) (int64, error) {
 			return 0, err
 		}
 	}
+	c.metrics.addDeleted(key, reply)
 	return reply, nil
 }
 
+// ackAndDel acknowledges and deletes the message in a MULTI/EXEC
+// transaction.
+func (c *consumerClient) ackAndDel(key string, id string) error {
+	if c.disposed {
+		return fmt.Errorf("the Consumer has been disposed")
+	}
+	if !c.running {
+		return fmt.Errorf("the Consumer is not running")
+	}
+
+	c.wg.Add(1)
+	defer c.wg.Done()
+
+	pipe := c.client.TxPipeline()
+	ackCmd := pipe.XAck(key, c.Group, id)
+	delCmd := pipe.XDel(key, id)
+	_, err := pipe.Exec()
+	if err != nil {
+		if err != redis.Nil {
+			return err
+		}
+	}
+	c.metrics.addAcked(key, ackCmd.Val())
+	c.metrics.addDeleted(key, delCmd.Val())
+	return nil
+}
+
+// ackBatch sends XACK and XDEL of the specified message IDs grouped by
+// stream through a pipeline.
+func (c *consumerClient) ackBatch(acks map[string][]string, dels map[string][]string) error {
+	if c.disposed {
+		return fmt.Errorf("the Consumer has been disposed")
+	}
+	if !c.running {
+		return fmt.Errorf("the Consumer is not running")
+	}
+	if len(acks) == 0 && len(dels) == 0 {
+		return nil
+	}
+
+	c.wg.Add(1)
+	defer c.wg.Done()
+
+	var (
+		pipe    = c.client.Pipeline()
+		ackCmds = make(map[string]*redis.IntCmd, len(acks))
+		delCmds = make(map[string]*redis.IntCmd, len(dels))
+	)
+	for stream, ids := range acks {
+		ackCmds[stream] = pipe.XAck(stream, c.Group, ids...)
+	}
+	for stream, ids := range dels {
+		delCmds[stream] = pipe.XDel(stream, ids...)
+	}
+	_, err := pipe.Exec()
+	if err != nil {
+		if err != redis.Nil {
+			return err
+		}
+	}
+	for stream, cmd := range ackCmds {
+		c.metrics.addAcked(stream, cmd.Val())
+	}
+	for stream, cmd := range delCmds {
+		c.metrics.addDeleted(stream, cmd.Val())
+	}
+	return nil
+}
+
 func (c *consumerClient) pause(streams ...string) error {
+	c.streamMutex.Lock()
+	defer c.streamMutex.Unlock()
+
 	for _, s := range streams {
 		if _, ok := c.streamKeyState.Load(s); ok {
 			c.streamKeyState.Store(s, false)
@@ -274,6 +485,9 @@ func (c *consumerClient) pause(streams ...string) error {
 }
 
 func (c *consumerClient) resume(streams ...string) error {
+	c.streamMutex.Lock()
+	defer c.streamMutex.Unlock()
+
 	for _, s := range streams {
 		if _, ok := c.streamKeyState.Load(s); ok {
 			c.streamKeyState.Store(s, true)
@@ -283,6 +497,90 @@ func (c *consumerClient) resume(streams ...string) error {
 	return nil
 }
 
+func (c *consumerClient) addStreams(streams ...StreamOffsetInfo) error {
+	if c.disposed {
+		return fmt.Errorf("the Consumer has been disposed")
+	}
+	if !c.running {
+		return fmt.Errorf("the Consumer is not running")
+	}
+
+	streams, patterns := splitStreamPatterns(streams)
+
+	if c.AutoCreateGroup {
+		var keys = make([]string, 0, len(streams))
+		for _, s := range streams {
+			keys = append(keys, s.getStreamOffset().Stream)
+		}
+		if err := c.createGroup(keys...); err != nil {
+			return err
+		}
+	}
+
+	c.streamMutex.Lock()
+	for _, s := range streams {
+		k := s.getStreamOffset().Stream
+		if _, ok := c.streamKeyState.Load(k); ok {
+			continue
+		}
+
+		c.streamKeyState.Store(k, true)
+		c.streams = append(c.streams, s)
+		c.streamKeys = append(c.streamKeys, k)
+	}
+	c.streamPatterns = append(c.streamPatterns, patterns...)
+	c.updateStreamKeyOffset()
+	c.streamMutex.Unlock()
+
+	if len(patterns) > 0 {
+		return c.discoverStreams(patterns...)
+	}
+	return nil
+}
+
+func (c *consumerClient) removeStreams(streams ...string) error {
+	if c.disposed {
+		return fmt.Errorf("the Consumer has been disposed")
+	}
+	if !c.running {
+		return fmt.Errorf("the Consumer is not running")
+	}
+
+	c.streamMutex.Lock()
+	defer c.streamMutex.Unlock()
+
+	var removed = make(map[string]bool, len(streams))
+	for _, k := range streams {
+		if _, ok := c.streamKeyState.LoadAndDelete(k); ok {
+			removed[k] = true
+			delete(c.autoClaimCursors, k)
+			c.discoveredStreams.Delete(k)
+		}
+	}
+	if len(removed) == 0 {
+		return nil
+	}
+
+	var (
+		remainStreams = make([]StreamOffsetInfo, 0, len(c.streams))
+		remainKeys    = make([]string, 0, len(c.streamKeys))
+	)
+	for _, s := range c.streams {
+		if !removed[s.getStreamOffset().Stream] {
+			remainStreams = append(remainStreams, s)
+		}
+	}
+	for _, k := range c.streamKeys {
+		if !removed[k] {
+			remainKeys = append(remainKeys, k)
+		}
+	}
+	c.streams = remainStreams
+	c.streamKeys = remainKeys
+	c.updateStreamKeyOffset()
+	return nil
+}
+
 func (c *consumerClient) close() {
 	if c.disposed {
 		return
@@ -298,6 +596,40 @@ func (c *consumerClient) close() {
 
 	c.wg.Wait()
 	c.client.Close()
+
+	c.readMutex.Lock()
+	if c.readClient != nil {
+		c.readClient.Close()
+		c.readClient = nil
+	}
+	c.readMutex.Unlock()
+}
+
+// acquireReadClient returns the client of XREADGROUP, a new one is created
+// if the previous one has been closed by interruptRead().
+func (c *consumerClient) acquireReadClient() (UniversalClient, error) {
+	c.readMutex.Lock()
+	defer c.readMutex.Unlock()
+
+	if c.readClient == nil {
+		client, err := createRedisUniversalClient(c.RedisOption)
+		if err != nil {
+			return nil, err
+		}
+		c.readClient = client
+	}
+	return c.readClient, nil
+}
+
+// interruptRead closes the client to interrupt the blocking XREADGROUP.
+func (c *consumerClient) interruptRead(client UniversalClient) {
+	c.readMutex.Lock()
+	if c.readClient == client {
+		c.readClient = nil
+	}
+	c.readMutex.Unlock()
+
+	client.Close()
 }
 
 func (c *consumerClient) configRedisClient() error {
@@ -313,34 +645,541 @@ func (c *consumerClient) configRedisClient() error {
 }
 
 func (c *consumerClient) ackGhostIDs(stream string, ghostIDs ...string) error {
+	if len(ghostIDs) == 0 {
+		return nil
+	}
+
+	var (
+		pipe = c.client.Pipeline()
+		cmds = make([]*redis.XMessageSliceCmd, 0, len(ghostIDs))
+	)
 	for _, id := range ghostIDs {
-		reply, err := c.client.XRange(stream, id, id).Result()
+		cmds = append(cmds, pipe.XRange(stream, id, id))
+	}
+	_, err := pipe.Exec()
+	if err != nil {
+		if err != redis.Nil {
+			return err
+		}
+	}
+
+	var ids = make([]string, 0, len(ghostIDs))
+	for i, cmd := range cmds {
+		if len(cmd.Val()) == 0 {
+			ids = append(ids, ghostIDs[i])
+		}
+	}
+
+	if len(ids) > 0 {
+		err = c.client.XAck(stream, c.Group, ids...).Err()
 		if err != nil {
 			if err != redis.Nil {
 				return err
 			}
 		}
+		c.metrics.addGhostIDsPurged(stream, int64(len(ids)))
+	}
+	return nil
+}
+
+// autoClaim claims the idle pending messages by XAUTOCLAIM, and continues
+// scanning the PEL from the cursor returned by the previous call.
+func (c *consumerClient) autoClaim(stream string, minIdleTime time.Duration, count int64) ([]redis.XMessage, error) {
+	c.streamMutex.RLock()
+	cursor, ok := c.autoClaimCursors[stream]
+	c.streamMutex.RUnlock()
+	if !ok {
+		cursor = StreamZeroID
+	}
+
+	args := make([]interface{}, 0, 8)
+	args = append(args, "xautoclaim", stream, c.Group, c.Name, int64(minIdleTime/time.Millisecond), cursor)
+	if count > 0 {
+		args = append(args, "count", count)
+	}
+
+	reply, err := c.client.Do(args...).Result()
+	if err != nil {
+		if err != redis.Nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+
+	result, err := parseXAutoClaimReply(reply)
+	if err != nil {
+		return nil, err
+	}
+	c.streamMutex.Lock()
+	if _, ok := c.streamKeyState.Load(stream); ok {
+		c.autoClaimCursors[stream] = result.cursor
+	}
+	c.streamMutex.Unlock()
+
+	// NOTE: Redis 7 removes the deleted entries from the PEL and replies
+	// their IDs, but Redis 6.2 replies them with nil fields and keeps them
+	// in the PEL.
+	if len(result.ghostIDs) > 0 {
+		if err := c.ackGhostIDs(stream, result.ghostIDs...); err != nil {
+			return nil, err
+		}
+	}
+	c.metrics.addGhostIDsPurged(stream, int64(len(result.deletedIDs)))
+
+	var messages = result.messages
+	if c.retryScheduler != nil {
+		messages = messages[:0]
+		for _, m := range result.messages {
+			// skip the message which is waiting for retry
+			if c.retryScheduler.contains(stream, m.ID) {
+				continue
+			}
+			messages = append(messages, m)
+		}
+	}
+	return messages, nil
+}
+
+// createGroup creates the consumer group and the stream if they don't
+// exist. The existing groups are left untouched.
+func (c *consumerClient) createGroup(streams ...string) error {
+	var offset = c.GroupStartOffset
+	if len(offset) == 0 {
+		offset = StreamLastDeliveredID
+	}
+
+	for _, stream := range streams {
+		err := c.client.XGroupCreateMkStream(stream, c.Group, offset).Err()
+		if err != nil && !hasRedisErrorPrefix(err, "BUSYGROUP") {
+			return err
+		}
+	}
+	return nil
+}
+
+// hasGroup reports whether the consumer group exists on the stream.
+func (c *consumerClient) hasGroup(stream string) (bool, error) {
+	reply, err := c.client.Do("xinfo", "groups", stream).Result()
+	if err != nil {
+		if hasRedisErrorPrefix(err, "ERR no such key") {
+			return false, nil
+		}
+		return false, err
+	}
+	replies, ok := reply.([]interface{})
+	if !ok {
+		return false, fmt.Errorf("got %T, wanted []interface{}", reply)
+	}
+	for _, r := range replies {
+		group, err := parseGroupInfo(r)
+		if err != nil {
+			return false, err
+		}
+		if group.Name == c.Group {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// recoverGroup recreates the missing consumer groups if AutoCreateGroup
+// is set. The discovered streams which have been deleted, or have no
+// group without AutoCreateGroup, are removed instead of being recreated,
+// and the streams which have been removed meanwhile are skipped. The
+// cause is returned if nothing recovered.
+func (c *consumerClient) recoverGroup(cause error, streams ...string) error {
+	c.discoveryMutex.Lock()
+	defer c.discoveryMutex.Unlock()
+
+	var (
+		recreated = make([]string, 0, len(streams))
+		dropped   []string
+		skipped   bool
+	)
+	for _, stream := range streams {
+		if _, ok := c.streamKeyState.Load(stream); !ok {
+			skipped = true
+			continue
+		}
+		if _, ok := c.discoveredStreams.Load(stream); ok {
+			if c.AutoCreateGroup {
+				n, err := c.client.Exists(stream).Result()
+				if err != nil {
+					return err
+				}
+				if n == 0 {
+					dropped = append(dropped, stream)
+					continue
+				}
+			} else {
+				ok, err := c.hasGroup(stream)
+				if err != nil {
+					return err
+				}
+				if !ok {
+					dropped = append(dropped, stream)
+					continue
+				}
+			}
+		}
+		recreated = append(recreated, stream)
+	}
+
+	if len(dropped) > 0 {
+		if err := c.removeStreams(dropped...); err != nil {
+			return err
+		}
+	}
+	if c.AutoCreateGroup {
+		return c.createGroup(recreated...)
+	}
+	if len(dropped) == 0 && !skipped {
+		return cause
+	}
+	return nil
+}
+
+func (c *consumerClient) canRecoverGroup(err error) bool {
+	if !hasRedisErrorPrefix(err, "NOGROUP") {
+		return false
+	}
+	return c.AutoCreateGroup || c.hasStreamPatterns()
+}
+
+// discoverStreams subscribes the streams matched the patterns, and
+// unsubscribes the discovered streams which no longer exist. The streams
+// without the group are skipped unless AutoCreateGroup is set.
+func (c *consumerClient) discoverStreams(patterns ...StreamPattern) error {
+	c.discoveryMutex.Lock()
+	defer c.discoveryMutex.Unlock()
+
+	for _, p := range patterns {
+		keys, err := c.scanStreams(p.Pattern)
+		if err != nil {
+			return err
+		}
+
+		var (
+			matched = make(map[string]bool, len(keys))
+			added   = make([]StreamOffsetInfo, 0, len(keys))
+			removed []string
+		)
+		for _, k := range keys {
+			if matched[k] {
+				continue
+			}
+			matched[k] = true
+
+			if _, ok := c.streamKeyState.Load(k); ok {
+				continue
+			}
+			// the stream without the group cannot be read unless
+			// AutoCreateGroup is set, it is left for the next discovery.
+			if !c.AutoCreateGroup {
+				ok, err := c.hasGroup(k)
+				if err != nil {
+					return err
+				}
+				if !ok {
+					continue
+				}
+			}
+			c.discoveredStreams.Store(k, p.Pattern)
+			added = append(added, p.Stream(k))
+		}
+		c.discoveredStreams.Range(func(key, value interface{}) bool {
+			if value.(string) == p.Pattern && !matched[key.(string)] {
+				removed = append(removed, key.(string))
+			}
+			return true
+		})
+
+		if len(added) > 0 {
+			if err := c.addStreams(added...); err != nil {
+				return err
+			}
+		}
+		if len(removed) > 0 {
+			if err := c.removeStreams(removed...); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (c *consumerClient) refreshStreams() error {
+	c.streamMutex.RLock()
+	patterns := c.streamPatterns
+	c.streamMutex.RUnlock()
+
+	if len(patterns) == 0 {
+		return nil
+	}
+	return c.discoverStreams(patterns...)
+}
+
+func (c *consumerClient) hasStreamPatterns() bool {
+	c.streamMutex.RLock()
+	defer c.streamMutex.RUnlock()
 
-		if len(reply) == 0 {
-			err = c.client.XAck(stream, c.Group, id).Err()
+	return len(c.streamPatterns) > 0
+}
+
+// scanStreams scans the stream keys on each master in cluster mode.
+func (c *consumerClient) scanStreams(pattern string) ([]string, error) {
+	var withType = c.serverVersion.atLeast(6, 0)
+
+	if cluster, ok := c.client.(*redis.ClusterClient); ok {
+		var (
+			mutex sync.Mutex
+			keys  []string
+		)
+		err := cluster.ForEachMaster(func(master *redis.Client) error {
+			masterKeys, err := scanStreamKeys(master, pattern, DEFAULT_STREAM_SCAN_COUNT, withType)
 			if err != nil {
-				if err != redis.Nil {
+				return err
+			}
+			mutex.Lock()
+			keys = append(keys, masterKeys...)
+			mutex.Unlock()
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		return keys, nil
+	}
+	return scanStreamKeys(c.client, pattern, DEFAULT_STREAM_SCAN_COUNT, withType)
+}
+
+func (c *consumerClient) hasRetryScheduled(stream string) bool {
+	return c.retryScheduler != nil && c.retryScheduler.hasStream(stream)
+}
+
+func (c *consumerClient) canAutoClaim() bool {
+	// XAUTOCLAIM doesn't reply the delivery counter which is required
+	// by dead-lettering
+	if c.MaxDeliveryCount > 0 {
+		return false
+	}
+	return c.serverVersion.atLeast(6, 2)
+}
+
+func (c *consumerClient) detectServerVersion() {
+	info, err := c.client.Info("server").Result()
+	if err != nil {
+		return
+	}
+
+	if version, ok := parseRedisServerVersion(info); ok {
+		c.serverVersion = version
+	}
+}
+
+// deadLetter moves the specified pending messages to the DeadLetterStream
+// and acknowledges them on the source stream. The messages are discarded
+// if the DeadLetterStream is unset.
+// NOTE: XADD and XACK are sent separately since the streams might be in
+// different hash slots, the message might be dead-lettered twice if XACK
+// fails.
+func (c *consumerClient) deadLetter(stream string, minIdleTime time.Duration, reason string, ids ...string) error {
+	// take over the messages and fetch their content
+	messages, err := c.client.XClaim(&redis.XClaimArgs{
+		Stream:   stream,
+		Group:    c.Group,
+		Consumer: c.Name,
+		MinIdle:  minIdleTime,
+		Messages: ids,
+	}).Result()
+	if err != nil {
+		if err != redis.Nil {
+			return err
+		}
+	}
+
+	// purge ghost IDs
+	if err := c.ackUnclaimedIDs(stream, ids, messages); err != nil {
+		return err
+	}
+
+	// the long reason is truncated to fit in the MessageState
+	reason = truncateString(reason, MESSAGE_STATE_VALUE_MAX_SIZE)
+
+	for _, m := range messages {
+		if len(c.DeadLetterStream) > 0 {
+			var (
+				content = DecodeMessageContent(m.Values)
+				values  = make(map[string]interface{}, len(m.Values)+4)
+			)
+			for _, kv := range [][2]string{
+				{MESSAGE_STATE_DEAD_LETTER_ORIGIN_ID, m.ID},
+				{MESSAGE_STATE_DEAD_LETTER_ORIGIN_STREAM, stream},
+				{MESSAGE_STATE_DEAD_LETTER_ORIGIN_GROUP, c.Group},
+				{MESSAGE_STATE_DEAD_LETTER_REASON, reason},
+			} {
+				if _, err := content.State.Set(kv[0], kv[1]); err != nil {
 					return err
 				}
 			}
+			content.WriteTo(values)
+
+			err := c.client.XAdd(&redis.XAddArgs{
+				Stream: c.DeadLetterStream,
+				ID:     StreamAsteriskID,
+				Values: values,
+			}).Err()
+			if err != nil {
+				return err
+			}
+		}
+
+		err := c.client.XAck(stream, c.Group, m.ID).Err()
+		if err != nil {
+			if err != redis.Nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// redeliver claims the specified pending messages to the consumer
+// immediately, the delivery counter of the messages will be incremented.
+func (c *consumerClient) redeliver(stream string, ids ...string) ([]redis.XMessage, error) {
+	if c.disposed {
+		return nil, fmt.Errorf("the Consumer has been disposed")
+	}
+	if !c.running {
+		return nil, fmt.Errorf("the Consumer is not running")
+	}
+
+	c.wg.Add(1)
+	defer c.wg.Done()
+
+	messages, err := c.client.XClaim(&redis.XClaimArgs{
+		Stream:   stream,
+		Group:    c.Group,
+		Consumer: c.Name,
+		MinIdle:  0,
+		Messages: ids,
+	}).Result()
+	if err != nil {
+		if err != redis.Nil {
+			return nil, err
+		}
+	}
+
+	// purge ghost IDs
+	if err := c.ackUnclaimedIDs(stream, ids, messages); err != nil {
+		return nil, err
+	}
+	return messages, nil
+}
+
+// touch resets the idle time of the specified pending messages without
+// incrementing their delivery counter.
+func (c *consumerClient) touch(stream string, ids ...string) error {
+	if c.disposed {
+		return fmt.Errorf("the Consumer has been disposed")
+	}
+	if !c.running {
+		return fmt.Errorf("the Consumer is not running")
+	}
+
+	c.wg.Add(1)
+	defer c.wg.Done()
+
+	args := make([]interface{}, 0, 8+len(ids))
+	args = append(args, "xclaim", stream, c.Group, c.Name, 0)
+	for _, id := range ids {
+		args = append(args, id)
+	}
+	args = append(args, "idle", 0, "justid")
+
+	err := c.client.Do(args...).Err()
+	if err != nil {
+		if err != redis.Nil {
+			return err
 		}
 	}
 	return nil
 }
 
+func (c *consumerClient) deliveryCount(stream string, id string) (int64, error) {
+	if c.disposed {
+		return 0, fmt.Errorf("the Consumer has been disposed")
+	}
+	if !c.running {
+		return 0, fmt.Errorf("the Consumer is not running")
+	}
+
+	c.wg.Add(1)
+	defer c.wg.Done()
+
+	pendingSet, err := c.client.XPendingExt(&redis.XPendingExtArgs{
+		Stream: stream,
+		Group:  c.Group,
+		Start:  id,
+		End:    id,
+		Count:  1,
+	}).Result()
+	if err != nil {
+		if err != redis.Nil {
+			return 0, err
+		}
+	}
+	if len(pendingSet) == 0 {
+		return 0, nil
+	}
+	return pendingSet[0].RetryCount, nil
+}
+
+// ackUnclaimedIDs purges the ghost IDs which are absent from the
+// XCLAIM reply.
+func (c *consumerClient) ackUnclaimedIDs(stream string, ids []string, claimMessages []redis.XMessage) error {
+	if len(claimMessages) == len(ids) {
+		return nil
+	}
+
+	var (
+		claimedIDs = make(map[string]bool, len(claimMessages))
+		ghostIDs   []string
+	)
+	for _, m := range claimMessages {
+		claimedIDs[m.ID] = true
+	}
+	for _, id := range ids {
+		if !claimedIDs[id] {
+			ghostIDs = append(ghostIDs, id)
+		}
+	}
+	return c.ackGhostIDs(stream, ghostIDs...)
+}
+
 func (c *consumerClient) isConnected(stream string) bool {
 	if v, ok := c.streamKeyState.Load(stream); ok {
 		return v.(bool)
 	}
-	c.updateStreamKeyOffset()
 	return false
 }
 
+func (c *consumerClient) getStreamKeys() []string {
+	c.streamMutex.RLock()
+	defer c.streamMutex.RUnlock()
+
+	return c.streamKeys
+}
+
+func (c *consumerClient) getStreamKeyOffsets() []string {
+	c.streamMutex.RLock()
+	defer c.streamMutex.RUnlock()
+
+	return c.streamKeyOffsets
+}
+
+// updateStreamKeyOffset rebuilds the streamKeyOffsets, the caller must
+// hold the streamMutex.
 func (c *consumerClient) updateStreamKeyOffset() {
 	var (
 		size       = len(c.streams)
@@ -6,6 +6,7 @@ import (
 	"io"
 	"log"
 	"os"
+	"reflect"
 	"strings"
 	"testing"
 	"time"
@@ -912,6 +913,260 @@ func TestConsumerClient_Read_WithPauseAndResume(t *testing.T) {
 	}
 }
 
+func TestConsumerClient_Read_WithAddAndRemoveStreams(t *testing.T) {
+	{
+		/*
+			XGROUP CREATE gotestStream1 gotestGroup $ MKSTREAM
+			XGROUP CREATE gotestStream2 gotestGroup $ MKSTREAM
+
+			XADD gotestStream1 * name luffy age 19
+			XADD gotestStream1 * name nami age 21
+			XADD gotestStream2 * name roger age ??
+			XADD gotestStream2 * name ace age 22
+
+			XGROUP DESTROY gotestStream1 gotestGroup
+			XGROUP DESTROY gotestStream2 gotestGroup
+
+			DEL gotestStream1
+			DEL gotestStream2
+		*/
+		client := redis.NewClient(&redis.Options{
+			Addr: __TEST_REDIS_SERVER,
+			DB:   0,
+		})
+		if client == nil {
+			panic("fail to create redis.Client")
+		}
+		defer client.Close()
+
+		for _, cmd := range []string{
+			"XGROUP CREATE gotestStream1 gotestGroup $ MKSTREAM",
+			"XGROUP CREATE gotestStream2 gotestGroup $ MKSTREAM",
+
+			"XADD gotestStream1 * name luffy age 19",
+			"XADD gotestStream1 * name nami age 21",
+			"XADD gotestStream2 * name roger age ??",
+			"XADD gotestStream2 * name ace age 22",
+		} {
+			_, err := execRedisCommand(client, cmd).Result()
+			if err != nil {
+				panic(err)
+			}
+		}
+		defer func() {
+			for _, cmd := range []string{
+				"XGROUP DESTROY gotestStream1 gotestGroup",
+				"XGROUP DESTROY gotestStream2 gotestGroup",
+
+				"DEL gotestStream1",
+				"DEL gotestStream2",
+			} {
+				_, err := execRedisCommand(client, cmd).Result()
+				if err != nil {
+					panic(err)
+				}
+			}
+		}()
+	}
+
+	opt := redis.UniversalOptions{
+		Addrs: []string{__TEST_REDIS_SERVER},
+		DB:    0,
+	}
+
+	c := &consumerClient{
+		Group:       "gotestGroup",
+		Name:        "gotestConsumer",
+		RedisOption: &opt,
+	}
+
+	err := c.subscribe(
+		StreamOffset{Stream: "gotestStream1", Offset: StreamNeverDeliveredOffset},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = c.addStreams(
+		StreamOffset{Stream: "gotestStream1", Offset: StreamNeverDeliveredOffset},
+		StreamOffset{Stream: "gotestStream2", Offset: StreamNeverDeliveredOffset},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = c.removeStreams("gotestStream1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	{
+		var expectedStreamKeys = []string{"gotestStream2"}
+		if !reflect.DeepEqual(expectedStreamKeys, c.getStreamKeys()) {
+			t.Errorf("consumerClient.streamKeys expect:: %v, got:: %v\n", expectedStreamKeys, c.getStreamKeys())
+		}
+		var expectedStreamKeyOffsets = []string{"gotestStream2", string(StreamNeverDeliveredOffset)}
+		if !reflect.DeepEqual(expectedStreamKeyOffsets, c.getStreamKeyOffsets()) {
+			t.Errorf("consumerClient.streamKeyOffsets expect:: %v, got:: %v\n", expectedStreamKeyOffsets, c.getStreamKeyOffsets())
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	var msgCnt int = 0
+
+	run := true
+	for run {
+		select {
+		case <-ctx.Done():
+			t.Logf("done")
+			c.close()
+			run = false
+
+		default:
+			res, err := c.read(8, 10*time.Millisecond)
+			// t.Logf("%+v", res)
+			if err != nil {
+				if err != redis.Nil {
+					t.Errorf("%+v\n", err)
+					return
+				}
+			} else {
+				if len(res) > 0 {
+					for _, stream := range res {
+						for _, message := range stream.Messages {
+							log.Printf("Stream: %s, Message:%+v\n", stream.Stream, message)
+							c.client.XAck(stream.Stream, c.Group, message.ID)
+							msgCnt++
+						}
+					}
+				}
+			}
+		}
+	}
+
+	// assert
+	{
+		var expectedMsgCnt int = 2
+		if msgCnt != expectedMsgCnt {
+			t.Errorf("expect %d messages, but got %d messages", expectedMsgCnt, msgCnt)
+		}
+	}
+}
+
+func TestConsumerClient_Read_WithAutoCreateGroup(t *testing.T) {
+	client := redis.NewClient(&redis.Options{
+		Addr: __TEST_REDIS_SERVER,
+		DB:   0,
+	})
+	if client == nil {
+		panic("fail to create redis.Client")
+	}
+	defer client.Close()
+
+	{
+		/*
+			XADD gotestStream1 * name luffy age 19
+			XADD gotestStream1 * name nami age 21
+			XADD gotestStream2 * name roger age ??
+			XADD gotestStream2 * name ace age 22
+
+			DEL gotestStream1
+			DEL gotestStream2
+		*/
+		for _, cmd := range []string{
+			"XADD gotestStream1 * name luffy age 19",
+			"XADD gotestStream1 * name nami age 21",
+			"XADD gotestStream2 * name roger age ??",
+			"XADD gotestStream2 * name ace age 22",
+		} {
+			_, err := execRedisCommand(client, cmd).Result()
+			if err != nil {
+				panic(err)
+			}
+		}
+		defer func() {
+			for _, cmd := range []string{
+				"DEL gotestStream1",
+				"DEL gotestStream2",
+			} {
+				_, err := execRedisCommand(client, cmd).Result()
+				if err != nil {
+					panic(err)
+				}
+			}
+		}()
+	}
+
+	opt := redis.UniversalOptions{
+		Addrs: []string{__TEST_REDIS_SERVER},
+		DB:    0,
+	}
+
+	c := &consumerClient{
+		Group:            "gotestGroup",
+		Name:             "gotestConsumer",
+		RedisOption:      &opt,
+		AutoCreateGroup:  true,
+		GroupStartOffset: StreamZeroID,
+	}
+
+	err := c.subscribe(
+		StreamOffset{Stream: "gotestStream1", Offset: StreamNeverDeliveredOffset},
+		StreamOffset{Stream: "gotestStream2", Offset: StreamNeverDeliveredOffset},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.close()
+
+	var msgCnt int = 0
+
+	readAll := func(timeout time.Duration) {
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+
+		for ctx.Err() == nil {
+			res, err := c.read(8, 10*time.Millisecond)
+			if err != nil {
+				if err != redis.Nil {
+					t.Errorf("%+v\n", err)
+					return
+				}
+			}
+			for _, stream := range res {
+				for _, message := range stream.Messages {
+					log.Printf("Stream: %s, Message:%+v\n", stream.Stream, message)
+					c.client.XAck(stream.Stream, c.Group, message.ID)
+					msgCnt++
+				}
+			}
+		}
+	}
+
+	readAll(1 * time.Second)
+	{
+		var expectedMsgCnt int = 4
+		if msgCnt != expectedMsgCnt {
+			t.Errorf("expect %d messages, but got %d messages", expectedMsgCnt, msgCnt)
+		}
+	}
+
+	// the group is deleted while the consumer is running
+	_, err = execRedisCommand(client, "XGROUP DESTROY gotestStream1 gotestGroup").Result()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	readAll(1 * time.Second)
+	{
+		var expectedMsgCnt int = 6
+		if msgCnt != expectedMsgCnt {
+			t.Errorf("expect %d messages, but got %d messages", expectedMsgCnt, msgCnt)
+		}
+	}
+}
+
 func TestConsumerClient_Claim_WithPauseAndResume(t *testing.T) {
 	{
 		/*
@@ -1031,3 +1286,371 @@ func TestConsumerClient_Claim_WithPauseAndResume(t *testing.T) {
 		}
 	}
 }
+
+func TestConsumerClient_Claim_WithDeadLetter(t *testing.T) {
+	client := redis.NewClient(&redis.Options{
+		Addr: __TEST_REDIS_SERVER,
+		DB:   0,
+	})
+	if client == nil {
+		panic("fail to create redis.Client")
+	}
+	defer client.Close()
+
+	{
+		/*
+			XGROUP CREATE gotestStream1 gotestGroup $ MKSTREAM
+
+			XADD gotestStream1 1-0 name luffy age 19
+			XADD gotestStream1 2-0 name nami age 21
+
+			XREADGROUP GROUP gotestGroup gotest-main COUNT 8 STREAMS gotestStream1 >
+			XCLAIM gotestStream1 gotestGroup gotest-main 0 1-0
+			XCLAIM gotestStream1 gotestGroup gotest-main 0 1-0
+
+			XGROUP DESTROY gotestStream1 gotestGroup
+
+			DEL gotestStream1
+			DEL gotestDeadLetterStream
+		*/
+		for _, cmd := range []string{
+			"XGROUP CREATE gotestStream1 gotestGroup $ MKSTREAM",
+
+			"XADD gotestStream1 1-0 name luffy age 19",
+			"XADD gotestStream1 2-0 name nami age 21",
+
+			"XREADGROUP GROUP gotestGroup gotest-main COUNT 8 STREAMS gotestStream1 >",
+			"XCLAIM gotestStream1 gotestGroup gotest-main 0 1-0",
+			"XCLAIM gotestStream1 gotestGroup gotest-main 0 1-0",
+		} {
+			_, err := execRedisCommand(client, cmd).Result()
+			if err != nil {
+				panic(err)
+			}
+		}
+		defer func() {
+			for _, cmd := range []string{
+				"XGROUP DESTROY gotestStream1 gotestGroup",
+
+				"DEL gotestStream1",
+				"DEL gotestDeadLetterStream",
+			} {
+				_, err := execRedisCommand(client, cmd).Result()
+				if err != nil {
+					panic(err)
+				}
+			}
+		}()
+	}
+
+	opt := redis.UniversalOptions{
+		Addrs: []string{__TEST_REDIS_SERVER},
+		DB:    0,
+	}
+
+	c := &consumerClient{
+		Group:            "gotestGroup",
+		Name:             "gotestConsumer",
+		RedisOption:      &opt,
+		MaxDeliveryCount: 3,
+		DeadLetterStream: "gotestDeadLetterStream",
+	}
+
+	err := c.subscribe(
+		StreamOffset{Stream: "gotestStream1", Offset: StreamNeverDeliveredOffset},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.close()
+
+	res, err := c.claim(0, 8, 16)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// assert
+	{
+		var claimedIDs []string
+		for _, stream := range res {
+			for _, message := range stream.Messages {
+				claimedIDs = append(claimedIDs, message.ID)
+			}
+		}
+		var expectedClaimedIDs = []string{"2-0"}
+		if !reflect.DeepEqual(expectedClaimedIDs, claimedIDs) {
+			t.Errorf("claimed IDs expect:: %v, got:: %v\n", expectedClaimedIDs, claimedIDs)
+		}
+	}
+	{
+		messages, err := client.XRange("gotestDeadLetterStream", "-", "+").Result()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(messages) != 1 {
+			t.Fatalf("expect %d dead letters, but got %d", 1, len(messages))
+		}
+
+		content := DecodeMessageContent(messages[0].Values)
+		expectedValues := map[string]interface{}{
+			"name": "luffy",
+			"age":  "19",
+		}
+		if !reflect.DeepEqual(expectedValues, content.Values) {
+			t.Errorf("MessageContent.Values expect:: %v, got:: %v\n", expectedValues, content.Values)
+		}
+		expectedState := map[string]interface{}{
+			MESSAGE_STATE_DEAD_LETTER_ORIGIN_ID:     "1-0",
+			MESSAGE_STATE_DEAD_LETTER_ORIGIN_STREAM: "gotestStream1",
+			MESSAGE_STATE_DEAD_LETTER_ORIGIN_GROUP:  "gotestGroup",
+			MESSAGE_STATE_DEAD_LETTER_REASON:        "exceeded max delivery count 3",
+		}
+		state := make(map[string]interface{})
+		content.State.Visit(func(name string, value interface{}) {
+			state[name] = value
+		})
+		if !reflect.DeepEqual(expectedState, state) {
+			t.Errorf("MessageContent.State expect:: %v, got:: %v\n", expectedState, state)
+		}
+	}
+	{
+		pending, err := client.XPending("gotestStream1", "gotestGroup").Result()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var expectedPendingCount int64 = 1
+		if expectedPendingCount != pending.Count {
+			t.Errorf("pending count expect:: %v, got:: %v\n", expectedPendingCount, pending.Count)
+		}
+	}
+}
+
+func TestConsumerClient_Claim_WithXAutoClaim(t *testing.T) {
+	client := redis.NewClient(&redis.Options{
+		Addr: __TEST_REDIS_SERVER,
+		DB:   0,
+	})
+	if client == nil {
+		panic("fail to create redis.Client")
+	}
+	defer client.Close()
+
+	{
+		/*
+			XGROUP CREATE gotestStream1 gotestGroup $ MKSTREAM
+
+			XADD gotestStream1 1-0 name luffy age 19
+			XADD gotestStream1 2-0 name nami age 21
+			XADD gotestStream1 3-0 name zoro age 21
+
+			XREADGROUP GROUP gotestGroup gotest-main COUNT 8 STREAMS gotestStream1 >
+			XDEL gotestStream1 2-0
+
+			XGROUP DESTROY gotestStream1 gotestGroup
+
+			DEL gotestStream1
+		*/
+		for _, cmd := range []string{
+			"XGROUP CREATE gotestStream1 gotestGroup $ MKSTREAM",
+
+			"XADD gotestStream1 1-0 name luffy age 19",
+			"XADD gotestStream1 2-0 name nami age 21",
+			"XADD gotestStream1 3-0 name zoro age 21",
+
+			"XREADGROUP GROUP gotestGroup gotest-main COUNT 8 STREAMS gotestStream1 >",
+			"XDEL gotestStream1 2-0",
+		} {
+			_, err := execRedisCommand(client, cmd).Result()
+			if err != nil {
+				panic(err)
+			}
+		}
+		defer func() {
+			for _, cmd := range []string{
+				"XGROUP DESTROY gotestStream1 gotestGroup",
+
+				"DEL gotestStream1",
+			} {
+				_, err := execRedisCommand(client, cmd).Result()
+				if err != nil {
+					panic(err)
+				}
+			}
+		}()
+	}
+
+	opt := redis.UniversalOptions{
+		Addrs: []string{__TEST_REDIS_SERVER},
+		DB:    0,
+	}
+
+	c := &consumerClient{
+		Group:       "gotestGroup",
+		Name:        "gotestConsumer",
+		RedisOption: &opt,
+	}
+
+	err := c.subscribe(
+		StreamOffset{Stream: "gotestStream1", Offset: StreamNeverDeliveredOffset},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.close()
+
+	// force using XAUTOCLAIM
+	c.serverVersion = redisServerVersion{major: 7}
+
+	var claimedIDs []string
+	for i := 0; i < 3; i++ {
+		res, err := c.claim(0, 1, 16)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, stream := range res {
+			for _, message := range stream.Messages {
+				claimedIDs = append(claimedIDs, message.ID)
+			}
+		}
+	}
+
+	// assert
+	{
+		var expectedClaimedIDs = []string{"1-0", "3-0", "1-0"}
+		if !reflect.DeepEqual(expectedClaimedIDs, claimedIDs) {
+			t.Errorf("claimed IDs expect:: %v, got:: %v\n", expectedClaimedIDs, claimedIDs)
+		}
+	}
+	{
+		pending, err := client.XPending("gotestStream1", "gotestGroup").Result()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var expectedPendingCount int64 = 2
+		if expectedPendingCount != pending.Count {
+			t.Errorf("pending count expect:: %v, got:: %v\n", expectedPendingCount, pending.Count)
+		}
+	}
+}
+
+func TestConsumerClient_Claim_WithXAutoClaimAndRetry(t *testing.T) {
+	client := redis.NewClient(&redis.Options{
+		Addr: __TEST_REDIS_SERVER,
+		DB:   0,
+	})
+	if client == nil {
+		panic("fail to create redis.Client")
+	}
+	defer client.Close()
+
+	{
+		/*
+			XGROUP CREATE gotestStream1 gotestGroup $ MKSTREAM
+
+			XADD gotestStream1 1-0 name luffy age 19
+			XADD gotestStream1 2-0 name nami age 21
+
+			XREADGROUP GROUP gotestGroup gotestConsumer COUNT 8 STREAMS gotestStream1 >
+
+			XGROUP DESTROY gotestStream1 gotestGroup
+
+			DEL gotestStream1
+		*/
+		for _, cmd := range []string{
+			"XGROUP CREATE gotestStream1 gotestGroup $ MKSTREAM",
+
+			"XADD gotestStream1 1-0 name luffy age 19",
+			"XADD gotestStream1 2-0 name nami age 21",
+
+			"XREADGROUP GROUP gotestGroup gotestConsumer COUNT 8 STREAMS gotestStream1 >",
+		} {
+			_, err := execRedisCommand(client, cmd).Result()
+			if err != nil {
+				panic(err)
+			}
+		}
+		defer func() {
+			for _, cmd := range []string{
+				"XGROUP DESTROY gotestStream1 gotestGroup",
+
+				"DEL gotestStream1",
+			} {
+				_, err := execRedisCommand(client, cmd).Result()
+				if err != nil {
+					panic(err)
+				}
+			}
+		}()
+	}
+
+	opt := redis.UniversalOptions{
+		Addrs: []string{__TEST_REDIS_SERVER},
+		DB:    0,
+	}
+
+	c := &consumerClient{
+		Group:          "gotestGroup",
+		Name:           "gotestConsumer",
+		RedisOption:    &opt,
+		retryScheduler: newRetryScheduler(),
+	}
+
+	err := c.subscribe(
+		StreamOffset{Stream: "gotestStream1", Offset: StreamNeverDeliveredOffset},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.close()
+
+	// force using XAUTOCLAIM
+	c.serverVersion = redisServerVersion{major: 7}
+
+	// the message 1-0 is waiting for retry
+	c.retryScheduler.schedule("gotestStream1", "1-0", time.Now().Add(1*time.Hour))
+
+	const minIdleTime = 50 * time.Millisecond
+
+	var claimedIDs []string
+	for i := 0; i < 3; i++ {
+		time.Sleep(2 * minIdleTime)
+
+		res, err := c.claim(minIdleTime, 8, 16)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, stream := range res {
+			for _, message := range stream.Messages {
+				claimedIDs = append(claimedIDs, message.ID)
+			}
+		}
+	}
+
+	// assert
+	{
+		var expectedClaimedIDs = []string{"2-0", "2-0", "2-0"}
+		if !reflect.DeepEqual(expectedClaimedIDs, claimedIDs) {
+			t.Errorf("claimed IDs expect:: %v, got:: %v\n", expectedClaimedIDs, claimedIDs)
+		}
+	}
+	{
+		pending, err := client.XPendingExt(&redis.XPendingExtArgs{
+			Stream: "gotestStream1",
+			Group:  "gotestGroup",
+			Start:  "1-0",
+			End:    "1-0",
+			Count:  1,
+		}).Result()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(pending) != 1 {
+			t.Fatalf("expect %d pending messages, but got %d", 1, len(pending))
+		}
+		// the delivery counter of the message waiting for retry is untouched
+		var expectedRetryCount int64 = 1
+		if expectedRetryCount != pending[0].RetryCount {
+			t.Errorf("pending retry count expect:: %v, got:: %v\n", expectedRetryCount, pending[0].RetryCount)
+		}
+	}
+}
@@ -1,24 +1,163 @@
 package redis
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
 	_ "unsafe"
+
+	redis "github.com/go-redis/redis/v7"
 )
 
 var _ error = new(ConsumerError)
 
 type ConsumerError struct {
 	err error
+
+	stream    string
+	messageID string
+	stack     []byte
+	panicked  bool
+}
+
+func newPanicConsumerError(recovered interface{}, stack []byte, msg *Message) *ConsumerError {
+	e := &ConsumerError{
+		stack:    stack,
+		panicked: true,
+	}
+	if msg != nil {
+		e.stream = msg.Stream
+		if msg.XMessage != nil {
+			e.messageID = msg.ID
+		}
+	}
+
+	var subject = "messages"
+	if msg != nil {
+		subject = fmt.Sprintf("message '%s' on stream '%s'", e.messageID, e.stream)
+	}
+
+	switch v := recovered.(type) {
+	case error:
+		e.err = fmt.Errorf("panic while handling %s: %w", subject, v)
+	default:
+		e.err = fmt.Errorf("panic while handling %s: %v", subject, v)
+	}
+	return e
 }
 
 func (e *ConsumerError) Error() string {
-	return e.Error()
+	return e.err.Error()
 }
 
 func (e *ConsumerError) Unwrap() error {
 	return e.err
 }
 
+// Stream returns the stream of the message which caused the error.
+func (e *ConsumerError) Stream() string {
+	return e.stream
+}
+
+// MessageID returns the ID of the message which caused the error.
+func (e *ConsumerError) MessageID() string {
+	return e.messageID
+}
+
+// Stack returns the stack trace captured when the MessageHandler panicked.
+func (e *ConsumerError) Stack() []byte {
+	return e.stack
+}
+
+// IsPanic reports whether the error is recovered from a panic of the
+// MessageHandler.
+func (e *ConsumerError) IsPanic() bool {
+	return e.panicked
+}
+
 func (e *ConsumerError) IsRedisError() bool {
 	_, ok := e.err.(RedisError)
 	return ok
 }
+
+// IsNetworkError reports whether the error is caused by the connection,
+// e.g. connection refused, reset or timeout.
+func (e *ConsumerError) IsNetworkError() bool {
+	return isNetworkError(e.err)
+}
+
+// IsTransient reports whether the error is temporary and the operation
+// might succeed if it is retried later, e.g. network errors or the Redis
+// server replies LOADING, CLUSTERDOWN, TRYAGAIN, MASTERDOWN.
+func (e *ConsumerError) IsTransient() bool {
+	return isTransientError(e.err)
+}
+
+// IsNoGroupError reports whether the consumer group or the stream
+// does not exist.
+func (e *ConsumerError) IsNoGroupError() bool {
+	return hasRedisErrorPrefix(e.err, "NOGROUP")
+}
+
+func (e *ConsumerError) IsCanceled() bool {
+	return errors.Is(e.err, context.Canceled) ||
+		errors.Is(e.err, context.DeadlineExceeded)
+}
+
+func isNetworkError(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, context.Canceled) ||
+		errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	if errors.Is(err, io.EOF) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, redis.ErrClosed) {
+		return true
+	}
+
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+func isTransientError(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, redis.ErrClosed) {
+		return false
+	}
+	if isNetworkError(err) {
+		return true
+	}
+
+	for _, prefix := range []string{
+		"LOADING",
+		"CLUSTERDOWN",
+		"TRYAGAIN",
+		"MASTERDOWN",
+		"READONLY",
+		"BUSY ",
+	} {
+		if hasRedisErrorPrefix(err, prefix) {
+			return true
+		}
+	}
+	return err.Error() == "ERR max number of clients reached"
+}
+
+func hasRedisErrorPrefix(err error, prefix string) bool {
+	var redisErr RedisError
+	if !errors.As(err, &redisErr) {
+		return false
+	}
+	if e, ok := redisErr.(error); ok {
+		return strings.HasPrefix(e.Error(), prefix)
+	}
+	return false
+}
@@ -0,0 +1,112 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"syscall"
+	"testing"
+)
+
+var _ RedisError = mockRedisError("")
+
+type mockRedisError string
+
+func (e mockRedisError) Error() string { return string(e) }
+
+// RedisError implements RedisError.
+func (e mockRedisError) RedisError() {}
+
+func TestConsumerError(t *testing.T) {
+	var (
+		connRefusedErr = &net.OpError{Op: "dial", Net: "tcp", Err: syscall.ECONNREFUSED}
+	)
+
+	cases := []struct {
+		err              error
+		expectedRedis    bool
+		expectedNetwork  bool
+		expectedTransit  bool
+		expectedNoGroup  bool
+		expectedCanceled bool
+	}{
+		{err: connRefusedErr, expectedNetwork: true, expectedTransit: true},
+		{err: fmt.Errorf("wrapped: %w", connRefusedErr), expectedNetwork: true, expectedTransit: true},
+		{err: io.EOF, expectedNetwork: true, expectedTransit: true},
+		{err: mockRedisError("LOADING Redis is loading the dataset in memory"), expectedRedis: true, expectedTransit: true},
+		{err: mockRedisError("CLUSTERDOWN The cluster is down"), expectedRedis: true, expectedTransit: true},
+		{err: mockRedisError("TRYAGAIN Multiple keys request during rehashing of slot"), expectedRedis: true, expectedTransit: true},
+		{err: mockRedisError("NOGROUP No such key 'gotestStream' or consumer group 'gotestGroup'"), expectedRedis: true, expectedNoGroup: true},
+		{err: mockRedisError("BUSYGROUP Consumer Group name already exists"), expectedRedis: true},
+		{err: mockRedisError("ERR syntax error"), expectedRedis: true},
+		{err: context.Canceled, expectedCanceled: true},
+		{err: context.DeadlineExceeded, expectedCanceled: true},
+	}
+
+	for _, c := range cases {
+		err := &ConsumerError{err: c.err}
+
+		if err.Error() != c.err.Error() {
+			t.Errorf("ConsumerError.Error() expect:: %v, got:: %v\n", c.err.Error(), err.Error())
+		}
+		if err.IsRedisError() != c.expectedRedis {
+			t.Errorf("ConsumerError{%v}.IsRedisError() expect:: %v, got:: %v\n", c.err, c.expectedRedis, err.IsRedisError())
+		}
+		if err.IsNetworkError() != c.expectedNetwork {
+			t.Errorf("ConsumerError{%v}.IsNetworkError() expect:: %v, got:: %v\n", c.err, c.expectedNetwork, err.IsNetworkError())
+		}
+		if err.IsTransient() != c.expectedTransit {
+			t.Errorf("ConsumerError{%v}.IsTransient() expect:: %v, got:: %v\n", c.err, c.expectedTransit, err.IsTransient())
+		}
+		if err.IsNoGroupError() != c.expectedNoGroup {
+			t.Errorf("ConsumerError{%v}.IsNoGroupError() expect:: %v, got:: %v\n", c.err, c.expectedNoGroup, err.IsNoGroupError())
+		}
+		if err.IsCanceled() != c.expectedCanceled {
+			t.Errorf("ConsumerError{%v}.IsCanceled() expect:: %v, got:: %v\n", c.err, c.expectedCanceled, err.IsCanceled())
+		}
+	}
+}
+
+func TestConsumerError_WithPanic(t *testing.T) {
+	var (
+		stack = []byte("goroutine 1 [running]:")
+		msg   = &Message{
+			Stream: "gotestStream",
+			XMessage: &XMessage{
+				ID: "1000-0",
+			},
+		}
+	)
+
+	{
+		err := newPanicConsumerError("boom", stack, msg)
+
+		if !err.IsPanic() {
+			t.Errorf("ConsumerError.IsPanic() should be true\n")
+		}
+		var expectedStream string = "gotestStream"
+		if expectedStream != err.Stream() {
+			t.Errorf("ConsumerError.Stream() expect:: %v, got:: %v\n", expectedStream, err.Stream())
+		}
+		var expectedMessageID string = "1000-0"
+		if expectedMessageID != err.MessageID() {
+			t.Errorf("ConsumerError.MessageID() expect:: %v, got:: %v\n", expectedMessageID, err.MessageID())
+		}
+		if string(stack) != string(err.Stack()) {
+			t.Errorf("ConsumerError.Stack() expect:: %s, got:: %s\n", stack, err.Stack())
+		}
+		var expectedError string = "panic while handling message '1000-0' on stream 'gotestStream': boom"
+		if expectedError != err.Error() {
+			t.Errorf("ConsumerError.Error() expect:: %v, got:: %v\n", expectedError, err.Error())
+		}
+	}
+	{
+		err := newPanicConsumerError(io.ErrUnexpectedEOF, stack, msg)
+
+		if !errors.Is(err, io.ErrUnexpectedEOF) {
+			t.Errorf("ConsumerError should wrap %v\n", io.ErrUnexpectedEOF)
+		}
+	}
+}
@@ -0,0 +1,28 @@
+package redis
+
+import "time"
+
+type ConsumerInfo struct {
+	Name     string
+	Pending  int64
+	Idle     time.Duration // 距離上次嘗試互動的時間
+	Inactive time.Duration // 距離上次成功互動的時間, Redis 7.2 起提供, 無法取得時為 -1
+}
+
+func parseConsumerInfo(reply interface{}) (*ConsumerInfo, error) {
+	info, err := parseInfoReply(reply)
+	if err != nil {
+		return nil, err
+	}
+
+	v := &ConsumerInfo{
+		Name:     infoString(info, "name"),
+		Inactive: -1,
+	}
+	v.Pending, _ = infoInt64(info, "pending")
+	v.Idle, _ = infoMilliseconds(info, "idle")
+	if inactive, ok := infoMilliseconds(info, "inactive"); ok {
+		v.Inactive = inactive
+	}
+	return v, nil
+}
@@ -3,6 +3,9 @@ package redis
 import (
 	"context"
 	"log"
+	"reflect"
+	"sort"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -111,3 +114,1125 @@ func TestConsumer_Subscribe(t *testing.T) {
 		}
 	}
 }
+
+func TestConsumer_SubscribeContext(t *testing.T) {
+	{
+		/*
+			XGROUP CREATE gotestStream1 gotestGroup 0 MKSTREAM
+
+			XADD gotestStream1 * name luffy age 19
+			XADD gotestStream1 * name nami age 21
+
+			XGROUP DESTROY gotestStream1 gotestGroup
+
+			DEL gotestStream1
+		*/
+		client := redis.NewClient(&redis.Options{
+			Addr: __TEST_REDIS_SERVER,
+			DB:   0,
+		})
+		if client == nil {
+			panic("fail to create redis.Client")
+		}
+		defer client.Close()
+
+		for _, cmd := range []string{
+			"XGROUP CREATE gotestStream1 gotestGroup 0 MKSTREAM",
+
+			"XADD gotestStream1 * name luffy age 19",
+			"XADD gotestStream1 * name nami age 21",
+		} {
+			_, err := execRedisCommand(client, cmd).Result()
+			if err != nil {
+				panic(err)
+			}
+		}
+		defer func() {
+			for _, cmd := range []string{
+				"XGROUP DESTROY gotestStream1 gotestGroup",
+
+				"DEL gotestStream1",
+			} {
+				_, err := execRedisCommand(client, cmd).Result()
+				if err != nil {
+					panic(err)
+				}
+			}
+		}()
+	}
+
+	opt := redis.UniversalOptions{
+		Addrs: []string{__TEST_REDIS_SERVER},
+		DB:    0,
+	}
+
+	var (
+		msgCnt      int32 = 0
+		canceledCnt int32 = 0
+	)
+
+	// NOTE: go-redis honors the deadline but not the cancellation of the
+	// context, use a cancel-only context to cover the interruption.
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	c := &Consumer{
+		Group:               "gotestGroup",
+		Name:                "gotestConsumer",
+		RedisOption:         &opt,
+		MaxInFlight:         8,
+		MaxPollingTimeout:   10 * time.Second,
+		ClaimMinIdleTime:    5 * time.Second,
+		IdlingTimeout:       10 * time.Second,
+		ClaimSensitivity:    8,
+		ClaimOccurrenceRate: 1,
+		Concurrency:         2,
+		ContextMessageHandler: func(ctx context.Context, message *Message) {
+			atomic.AddInt32(&msgCnt, 1)
+			message.Ack()
+
+			<-ctx.Done()
+			atomic.AddInt32(&canceledCnt, 1)
+		},
+	}
+
+	err := c.SubscribeContext(ctx,
+		Stream("gotestStream1").NeverDeliveredOffset(),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	time.Sleep(500 * time.Millisecond)
+	cancel()
+
+	// the blocking XREADGROUP should be interrupted by the context
+	select {
+	case <-c.Done():
+	case <-time.After(1 * time.Second):
+		t.Errorf("the Consumer should stop once the context canceled")
+	}
+	closeCtx, closeCancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer closeCancel()
+	c.CloseContext(closeCtx)
+
+	// assert
+	{
+		var expectedMsgCnt int32 = 2
+		if expectedMsgCnt != msgCnt {
+			t.Errorf("expect %d messages, but got %d messages", expectedMsgCnt, msgCnt)
+		}
+		var expectedCanceledCnt int32 = 2
+		if expectedCanceledCnt != canceledCnt {
+			t.Errorf("expect %d canceled handlers, but got %d", expectedCanceledCnt, canceledCnt)
+		}
+	}
+}
+
+func TestConsumer_Subscribe_WithNack(t *testing.T) {
+	{
+		/*
+			XGROUP CREATE gotestStream1 gotestGroup 0 MKSTREAM
+
+			XADD gotestStream1 * name luffy age 19
+
+			XGROUP DESTROY gotestStream1 gotestGroup
+
+			DEL gotestStream1
+		*/
+		client := redis.NewClient(&redis.Options{
+			Addr: __TEST_REDIS_SERVER,
+			DB:   0,
+		})
+		if client == nil {
+			panic("fail to create redis.Client")
+		}
+		defer client.Close()
+
+		for _, cmd := range []string{
+			"XGROUP CREATE gotestStream1 gotestGroup 0 MKSTREAM",
+
+			"XADD gotestStream1 * name luffy age 19",
+		} {
+			_, err := execRedisCommand(client, cmd).Result()
+			if err != nil {
+				panic(err)
+			}
+		}
+		defer func() {
+			for _, cmd := range []string{
+				"XGROUP DESTROY gotestStream1 gotestGroup",
+
+				"DEL gotestStream1",
+			} {
+				_, err := execRedisCommand(client, cmd).Result()
+				if err != nil {
+					panic(err)
+				}
+			}
+		}()
+	}
+
+	opt := redis.UniversalOptions{
+		Addrs: []string{__TEST_REDIS_SERVER},
+		DB:    0,
+	}
+
+	var (
+		deliveredAt []time.Time
+	)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	c := &Consumer{
+		Group:               "gotestGroup",
+		Name:                "gotestConsumer",
+		RedisOption:         &opt,
+		MaxInFlight:         8,
+		MaxPollingTimeout:   10 * time.Millisecond,
+		ClaimMinIdleTime:    5 * time.Second,
+		IdlingTimeout:       50 * time.Millisecond,
+		ClaimSensitivity:    8,
+		ClaimOccurrenceRate: 1,
+		RetryBackoff:        ConstantBackoff(500 * time.Millisecond),
+		MessageHandler: func(message *Message) {
+			deliveredAt = append(deliveredAt, time.Now())
+			switch len(deliveredAt) {
+			case 1:
+				message.Nack(200 * time.Millisecond)
+			case 2:
+				message.Retry()
+			default:
+				message.Ack()
+			}
+		},
+	}
+
+	err := c.Subscribe(
+		Stream("gotestStream1").NeverDeliveredOffset(),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	<-ctx.Done()
+	c.Close()
+
+	// assert
+	{
+		var expectedDeliveredCnt int = 3
+		if len(deliveredAt) != expectedDeliveredCnt {
+			t.Fatalf("expect %d deliveries, but got %d deliveries", expectedDeliveredCnt, len(deliveredAt))
+		}
+		if delay := deliveredAt[1].Sub(deliveredAt[0]); delay < 200*time.Millisecond {
+			t.Errorf("expect the 2nd delivery delayed at least %v, but got %v", 200*time.Millisecond, delay)
+		}
+		if delay := deliveredAt[2].Sub(deliveredAt[1]); delay < 500*time.Millisecond {
+			t.Errorf("expect the 3rd delivery delayed at least %v, but got %v", 500*time.Millisecond, delay)
+		}
+	}
+}
+
+func TestConsumer_Subscribe_WithNackAndMaxDeliveryCount(t *testing.T) {
+	client := redis.NewClient(&redis.Options{
+		Addr: __TEST_REDIS_SERVER,
+		DB:   0,
+	})
+	if client == nil {
+		panic("fail to create redis.Client")
+	}
+	defer client.Close()
+
+	{
+		/*
+			XGROUP CREATE gotestStream1 gotestGroup 0 MKSTREAM
+
+			XADD gotestStream1 * name luffy age 19
+
+			XGROUP DESTROY gotestStream1 gotestGroup
+
+			DEL gotestStream1
+			DEL gotestDeadLetterStream
+		*/
+		for _, cmd := range []string{
+			"XGROUP CREATE gotestStream1 gotestGroup 0 MKSTREAM",
+
+			"XADD gotestStream1 * name luffy age 19",
+		} {
+			_, err := execRedisCommand(client, cmd).Result()
+			if err != nil {
+				panic(err)
+			}
+		}
+		defer func() {
+			for _, cmd := range []string{
+				"XGROUP DESTROY gotestStream1 gotestGroup",
+
+				"DEL gotestStream1",
+				"DEL gotestDeadLetterStream",
+			} {
+				_, err := execRedisCommand(client, cmd).Result()
+				if err != nil {
+					panic(err)
+				}
+			}
+		}()
+	}
+
+	opt := redis.UniversalOptions{
+		Addrs: []string{__TEST_REDIS_SERVER},
+		DB:    0,
+	}
+
+	var deliveredCnt int32 = 0
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1500*time.Millisecond)
+	defer cancel()
+
+	c := &Consumer{
+		Group:               "gotestGroup",
+		Name:                "gotestConsumer",
+		RedisOption:         &opt,
+		MaxInFlight:         8,
+		MaxPollingTimeout:   10 * time.Millisecond,
+		ClaimMinIdleTime:    5 * time.Second,
+		IdlingTimeout:       10 * time.Millisecond,
+		ClaimSensitivity:    8,
+		ClaimOccurrenceRate: 1,
+		MaxDeliveryCount:    2,
+		DeadLetterStream:    "gotestDeadLetterStream",
+		MessageHandler: func(message *Message) {
+			atomic.AddInt32(&deliveredCnt, 1)
+			message.Nack(0)
+		},
+	}
+
+	err := c.Subscribe(
+		Stream("gotestStream1").NeverDeliveredOffset(),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	<-ctx.Done()
+	c.Close()
+
+	// assert
+	{
+		var expectedDeliveredCnt int32 = 2
+		if got := atomic.LoadInt32(&deliveredCnt); expectedDeliveredCnt != got {
+			t.Errorf("expect %d deliveries, but got %d deliveries", expectedDeliveredCnt, got)
+		}
+	}
+	{
+		msgCnt, err := client.XLen("gotestDeadLetterStream").Result()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var expectedMsgCnt int64 = 1
+		if expectedMsgCnt != msgCnt {
+			t.Errorf("expect %d dead letters, but got %d", expectedMsgCnt, msgCnt)
+		}
+	}
+	{
+		pending, err := client.XPending("gotestStream1", "gotestGroup").Result()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var expectedPendingCount int64 = 0
+		if expectedPendingCount != pending.Count {
+			t.Errorf("pending count expect:: %v, got:: %v\n", expectedPendingCount, pending.Count)
+		}
+	}
+}
+
+func TestConsumer_Subscribe_WithErrorPolicyStop(t *testing.T) {
+	opt := redis.UniversalOptions{
+		Addrs: []string{__TEST_REDIS_SERVER},
+		DB:    0,
+	}
+
+	var errCnt int = 0
+
+	c := &Consumer{
+		Group:               "gotestUnknownGroup",
+		Name:                "gotestConsumer",
+		RedisOption:         &opt,
+		MaxInFlight:         8,
+		MaxPollingTimeout:   10 * time.Millisecond,
+		ClaimMinIdleTime:    5 * time.Second,
+		IdlingTimeout:       10 * time.Millisecond,
+		ClaimSensitivity:    8,
+		ClaimOccurrenceRate: 1,
+		ErrorPolicy:         ErrorPolicyStop,
+		MessageHandler: func(message *Message) {
+			message.Ack()
+		},
+		ErrorHandler: func(err error) (disposed bool) {
+			errCnt++
+			return false
+		},
+	}
+
+	err := c.Subscribe(
+		Stream("gotestUnknownStream").NeverDeliveredOffset(),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	select {
+	case <-c.Done():
+	case <-time.After(3 * time.Second):
+		t.Fatal("the Consumer should be stopped")
+	}
+
+	// assert
+	{
+		var expectedErrCnt int = 1
+		if errCnt != expectedErrCnt {
+			t.Errorf("expect %d errors, but got %d errors", expectedErrCnt, errCnt)
+		}
+		if c.Err() == nil {
+			t.Fatal("Consumer.Err() should not be nil")
+		}
+		consumerErr := &ConsumerError{err: c.Err()}
+		if !consumerErr.IsNoGroupError() {
+			t.Errorf("Consumer.Err() should be NOGROUP error, but got %v", c.Err())
+		}
+	}
+}
+
+func TestConsumer_Subscribe_WithPanic(t *testing.T) {
+	{
+		/*
+			XGROUP CREATE gotestStream1 gotestGroup 0 MKSTREAM
+
+			XADD gotestStream1 * name luffy age 19
+
+			XGROUP DESTROY gotestStream1 gotestGroup
+
+			DEL gotestStream1
+		*/
+		client := redis.NewClient(&redis.Options{
+			Addr: __TEST_REDIS_SERVER,
+			DB:   0,
+		})
+		if client == nil {
+			panic("fail to create redis.Client")
+		}
+		defer client.Close()
+
+		for _, cmd := range []string{
+			"XGROUP CREATE gotestStream1 gotestGroup 0 MKSTREAM",
+
+			"XADD gotestStream1 * name luffy age 19",
+		} {
+			_, err := execRedisCommand(client, cmd).Result()
+			if err != nil {
+				panic(err)
+			}
+		}
+		defer func() {
+			for _, cmd := range []string{
+				"XGROUP DESTROY gotestStream1 gotestGroup",
+
+				"DEL gotestStream1",
+			} {
+				_, err := execRedisCommand(client, cmd).Result()
+				if err != nil {
+					panic(err)
+				}
+			}
+		}()
+	}
+
+	opt := redis.UniversalOptions{
+		Addrs: []string{__TEST_REDIS_SERVER},
+		DB:    0,
+	}
+
+	var (
+		msgCnt    int32 = 0
+		panicErrs []*ConsumerError
+	)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	c := &Consumer{
+		Group:               "gotestGroup",
+		Name:                "gotestConsumer",
+		RedisOption:         &opt,
+		MaxInFlight:         8,
+		MaxPollingTimeout:   10 * time.Millisecond,
+		ClaimMinIdleTime:    500 * time.Millisecond,
+		IdlingTimeout:       100 * time.Millisecond,
+		ClaimSensitivity:    8,
+		ClaimOccurrenceRate: 1,
+		Concurrency:         1,
+		MessageHandler: func(message *Message) {
+			if atomic.AddInt32(&msgCnt, 1) == 1 {
+				panic("boom")
+			}
+			message.Ack()
+		},
+		ErrorHandler: func(err error) (disposed bool) {
+			if e, ok := err.(*ConsumerError); ok && e.IsPanic() {
+				panicErrs = append(panicErrs, e)
+			}
+			return true
+		},
+	}
+
+	err := c.Subscribe(
+		Stream("gotestStream1").NeverDeliveredOffset(),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	<-ctx.Done()
+	c.Close()
+
+	// assert
+	{
+		// the panicked message should be redelivered by claim
+		var expectedMsgCnt int32 = 2
+		if msgCnt != expectedMsgCnt {
+			t.Errorf("expect %d messages, but got %d messages", expectedMsgCnt, msgCnt)
+		}
+		if len(panicErrs) != 1 {
+			t.Fatalf("expect %d panic errors, but got %d", 1, len(panicErrs))
+		}
+		var expectedStream string = "gotestStream1"
+		if expectedStream != panicErrs[0].Stream() {
+			t.Errorf("ConsumerError.Stream() expect:: %v, got:: %v\n", expectedStream, panicErrs[0].Stream())
+		}
+		if len(panicErrs[0].Stack()) == 0 {
+			t.Errorf("ConsumerError.Stack() should not be empty\n")
+		}
+	}
+}
+
+func TestConsumer_Subscribe_WithAckBatch(t *testing.T) {
+	client := redis.NewClient(&redis.Options{
+		Addr: __TEST_REDIS_SERVER,
+		DB:   0,
+	})
+	if client == nil {
+		panic("fail to create redis.Client")
+	}
+	defer client.Close()
+
+	{
+		/*
+			XGROUP CREATE gotestStream1 gotestGroup 0 MKSTREAM
+			XGROUP CREATE gotestStream2 gotestGroup 0 MKSTREAM
+
+			XADD gotestStream1 * name luffy age 19
+			XADD gotestStream1 * name nami age 21
+			XADD gotestStream1 * name zoro age 21
+			XADD gotestStream2 * name roger age ??
+			XADD gotestStream2 * name ace age 22
+
+			XGROUP DESTROY gotestStream1 gotestGroup
+			XGROUP DESTROY gotestStream2 gotestGroup
+
+			DEL gotestStream1
+			DEL gotestStream2
+		*/
+		for _, cmd := range []string{
+			"XGROUP CREATE gotestStream1 gotestGroup 0 MKSTREAM",
+			"XGROUP CREATE gotestStream2 gotestGroup 0 MKSTREAM",
+
+			"XADD gotestStream1 * name luffy age 19",
+			"XADD gotestStream1 * name nami age 21",
+			"XADD gotestStream1 * name zoro age 21",
+			"XADD gotestStream2 * name roger age ??",
+			"XADD gotestStream2 * name ace age 22",
+		} {
+			_, err := execRedisCommand(client, cmd).Result()
+			if err != nil {
+				panic(err)
+			}
+		}
+		defer func() {
+			for _, cmd := range []string{
+				"XGROUP DESTROY gotestStream1 gotestGroup",
+				"XGROUP DESTROY gotestStream2 gotestGroup",
+
+				"DEL gotestStream1",
+				"DEL gotestStream2",
+			} {
+				_, err := execRedisCommand(client, cmd).Result()
+				if err != nil {
+					panic(err)
+				}
+			}
+		}()
+	}
+
+	opt := redis.UniversalOptions{
+		Addrs: []string{__TEST_REDIS_SERVER},
+		DB:    0,
+	}
+
+	var msgCnt int = 0
+
+	c := &Consumer{
+		Group:               "gotestGroup",
+		Name:                "gotestConsumer",
+		RedisOption:         &opt,
+		MaxInFlight:         8,
+		MaxPollingTimeout:   10 * time.Millisecond,
+		ClaimMinIdleTime:    5 * time.Second,
+		IdlingTimeout:       100 * time.Millisecond,
+		ClaimSensitivity:    8,
+		ClaimOccurrenceRate: 1,
+		AckBatchSize:        100,
+		AckFlushInterval:    10 * time.Second,
+		MessageHandler: func(message *Message) {
+			msgCnt++
+			switch message.Stream {
+			case "gotestStream1":
+				message.AckAndDel()
+			default:
+				message.Ack()
+			}
+		},
+	}
+
+	err := c.Subscribe(
+		Stream("gotestStream1").NeverDeliveredOffset(),
+		Stream("gotestStream2").NeverDeliveredOffset(),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	time.Sleep(1 * time.Second)
+
+	// the buffered XACK haven't been flushed yet
+	{
+		pending, err := client.XPending("gotestStream2", "gotestGroup").Result()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var expectedPendingCount int64 = 2
+		if expectedPendingCount != pending.Count {
+			t.Errorf("pending count expect:: %v, got:: %v\n", expectedPendingCount, pending.Count)
+		}
+	}
+
+	c.Close()
+
+	// assert
+	{
+		var expectedMsgCnt int = 5
+		if msgCnt != expectedMsgCnt {
+			t.Errorf("expect %d messages, but got %d messages", expectedMsgCnt, msgCnt)
+		}
+	}
+	{
+		length, err := client.XLen("gotestStream1").Result()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var expectedLength int64 = 0
+		if expectedLength != length {
+			t.Errorf("stream length expect:: %v, got:: %v\n", expectedLength, length)
+		}
+	}
+	for _, stream := range []string{"gotestStream1", "gotestStream2"} {
+		pending, err := client.XPending(stream, "gotestGroup").Result()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var expectedPendingCount int64 = 0
+		if expectedPendingCount != pending.Count {
+			t.Errorf("%s pending count expect:: %v, got:: %v\n", stream, expectedPendingCount, pending.Count)
+		}
+	}
+}
+
+func TestConsumer_Subscribe_WithBatchMessageHandler(t *testing.T) {
+	{
+		/*
+			XGROUP CREATE gotestStream1 gotestGroup 0 MKSTREAM
+			XGROUP CREATE gotestStream2 gotestGroup 0 MKSTREAM
+
+			XADD gotestStream1 * name luffy age 19
+			XADD gotestStream1 * name nami age 21
+			XADD gotestStream1 * name zoro age 21
+			XADD gotestStream2 * name roger age ??
+			XADD gotestStream2 * name ace age 22
+
+			XGROUP DESTROY gotestStream1 gotestGroup
+			XGROUP DESTROY gotestStream2 gotestGroup
+
+			DEL gotestStream1
+			DEL gotestStream2
+		*/
+		client := redis.NewClient(&redis.Options{
+			Addr: __TEST_REDIS_SERVER,
+			DB:   0,
+		})
+		if client == nil {
+			panic("fail to create redis.Client")
+		}
+		defer client.Close()
+
+		for _, cmd := range []string{
+			"XGROUP CREATE gotestStream1 gotestGroup 0 MKSTREAM",
+			"XGROUP CREATE gotestStream2 gotestGroup 0 MKSTREAM",
+
+			"XADD gotestStream1 * name luffy age 19",
+			"XADD gotestStream1 * name nami age 21",
+			"XADD gotestStream1 * name zoro age 21",
+			"XADD gotestStream2 * name roger age ??",
+			"XADD gotestStream2 * name ace age 22",
+		} {
+			_, err := execRedisCommand(client, cmd).Result()
+			if err != nil {
+				panic(err)
+			}
+		}
+		defer func() {
+			for _, cmd := range []string{
+				"XGROUP DESTROY gotestStream1 gotestGroup",
+				"XGROUP DESTROY gotestStream2 gotestGroup",
+
+				"DEL gotestStream1",
+				"DEL gotestStream2",
+			} {
+				_, err := execRedisCommand(client, cmd).Result()
+				if err != nil {
+					panic(err)
+				}
+			}
+		}()
+	}
+
+	opt := redis.UniversalOptions{
+		Addrs: []string{__TEST_REDIS_SERVER},
+		DB:    0,
+	}
+
+	var batchSizes []int
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	c := &Consumer{
+		Group:               "gotestGroup",
+		Name:                "gotestConsumer",
+		RedisOption:         &opt,
+		MaxInFlight:         8,
+		MaxPollingTimeout:   10 * time.Millisecond,
+		ClaimMinIdleTime:    5 * time.Second,
+		IdlingTimeout:       1 * time.Second,
+		ClaimSensitivity:    8,
+		ClaimOccurrenceRate: 1,
+		BatchSize:           3,
+		BatchWindow:         100 * time.Millisecond,
+		BatchMessageHandler: func(messages []*Message) {
+			batchSizes = append(batchSizes, len(messages))
+			for _, message := range messages {
+				message.Ack()
+			}
+		},
+	}
+
+	err := c.Subscribe(
+		Stream("gotestStream1").NeverDeliveredOffset(),
+		Stream("gotestStream2").NeverDeliveredOffset(),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	<-ctx.Done()
+	c.Close()
+
+	// assert
+	{
+		var expectedBatchSizes = []int{3, 2}
+		if !reflect.DeepEqual(expectedBatchSizes, batchSizes) {
+			t.Errorf("batch sizes expect:: %v, got:: %v\n", expectedBatchSizes, batchSizes)
+		}
+	}
+}
+
+func TestConsumer_Subscribe_WithBatchWindow(t *testing.T) {
+	{
+		/*
+			XGROUP CREATE gotestStream1 gotestGroup 0 MKSTREAM
+
+			XADD gotestStream1 * name luffy age 19
+			XADD gotestStream1 * name nami age 21
+
+			XGROUP DESTROY gotestStream1 gotestGroup
+
+			DEL gotestStream1
+		*/
+		client := redis.NewClient(&redis.Options{
+			Addr: __TEST_REDIS_SERVER,
+			DB:   0,
+		})
+		if client == nil {
+			panic("fail to create redis.Client")
+		}
+		defer client.Close()
+
+		for _, cmd := range []string{
+			"XGROUP CREATE gotestStream1 gotestGroup 0 MKSTREAM",
+
+			"XADD gotestStream1 * name luffy age 19",
+			"XADD gotestStream1 * name nami age 21",
+		} {
+			_, err := execRedisCommand(client, cmd).Result()
+			if err != nil {
+				panic(err)
+			}
+		}
+		defer func() {
+			for _, cmd := range []string{
+				"XGROUP DESTROY gotestStream1 gotestGroup",
+
+				"DEL gotestStream1",
+			} {
+				_, err := execRedisCommand(client, cmd).Result()
+				if err != nil {
+					panic(err)
+				}
+			}
+		}()
+	}
+
+	opt := redis.UniversalOptions{
+		Addrs: []string{__TEST_REDIS_SERVER},
+		DB:    0,
+	}
+
+	var flushed = make(chan int, 1)
+
+	c := &Consumer{
+		Group:               "gotestGroup",
+		Name:                "gotestConsumer",
+		RedisOption:         &opt,
+		MaxInFlight:         8,
+		MaxPollingTimeout:   2 * time.Second,
+		ClaimMinIdleTime:    5 * time.Second,
+		IdlingTimeout:       10 * time.Millisecond,
+		ClaimSensitivity:    8,
+		ClaimOccurrenceRate: 1,
+		BatchSize:           8,
+		BatchWindow:         100 * time.Millisecond,
+		BatchMessageHandler: func(messages []*Message) {
+			for _, message := range messages {
+				message.Ack()
+			}
+			select {
+			case flushed <- len(messages):
+			default:
+			}
+		},
+	}
+
+	start := time.Now()
+	err := c.Subscribe(
+		Stream("gotestStream1").NeverDeliveredOffset(),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	// the partial batch is flushed within the BatchWindow instead of
+	// waiting for the MaxPollingTimeout
+	select {
+	case n := <-flushed:
+		var expectedBatchSize = 2
+		if expectedBatchSize != n {
+			t.Errorf("batch size expect:: %v, got:: %v\n", expectedBatchSize, n)
+		}
+		if elapsed := time.Since(start); elapsed >= time.Second {
+			t.Errorf("the batch should be flushed within %v, but got %v", time.Second, elapsed)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("the batch is not flushed")
+	}
+}
+
+func TestConsumer_Subscribe_WithStreamPattern(t *testing.T) {
+	client := redis.NewClient(&redis.Options{
+		Addr: __TEST_REDIS_SERVER,
+		DB:   0,
+	})
+	if client == nil {
+		panic("fail to create redis.Client")
+	}
+	defer client.Close()
+
+	{
+		/*
+			XGROUP CREATE gotestPattern:1 gotestGroup 0 MKSTREAM
+			XGROUP CREATE gotestPattern:2 gotestGroup 0 MKSTREAM
+
+			XADD gotestPattern:1 * name luffy age 19
+			XADD gotestPattern:1 * name nami age 21
+			XADD gotestPattern:2 * name roger age ??
+			XADD gotestPattern:2 * name ace age 22
+
+			DEL gotestPattern:1
+			DEL gotestPattern:2
+			DEL gotestPattern:3
+		*/
+		for _, cmd := range []string{
+			"XGROUP CREATE gotestPattern:1 gotestGroup 0 MKSTREAM",
+			"XGROUP CREATE gotestPattern:2 gotestGroup 0 MKSTREAM",
+
+			"XADD gotestPattern:1 * name luffy age 19",
+			"XADD gotestPattern:1 * name nami age 21",
+			"XADD gotestPattern:2 * name roger age ??",
+			"XADD gotestPattern:2 * name ace age 22",
+		} {
+			_, err := execRedisCommand(client, cmd).Result()
+			if err != nil {
+				panic(err)
+			}
+		}
+		defer func() {
+			for _, cmd := range []string{
+				"DEL gotestPattern:1",
+				"DEL gotestPattern:2",
+				"DEL gotestPattern:3",
+			} {
+				_, err := execRedisCommand(client, cmd).Result()
+				if err != nil {
+					panic(err)
+				}
+			}
+		}()
+	}
+
+	opt := redis.UniversalOptions{
+		Addrs: []string{__TEST_REDIS_SERVER},
+		DB:    0,
+	}
+
+	var msgCnt int32 = 0
+
+	c := &Consumer{
+		Group:               "gotestGroup",
+		Name:                "gotestConsumer",
+		RedisOption:         &opt,
+		MaxInFlight:         8,
+		MaxPollingTimeout:   10 * time.Millisecond,
+		ClaimMinIdleTime:    5 * time.Second,
+		IdlingTimeout:       10 * time.Millisecond,
+		ClaimSensitivity:    8,
+		ClaimOccurrenceRate: 1,
+		AutoCreateGroup:     true,
+		GroupStartOffset:    StreamZeroID,
+		DiscoveryInterval:   100 * time.Millisecond,
+		MessageHandler: func(message *Message) {
+			log.Printf("Stream: %s, Message:%+v\n", message.Stream, message)
+			atomic.AddInt32(&msgCnt, 1)
+			message.Ack()
+		},
+	}
+
+	err := c.Subscribe(
+		StreamMatch("gotestPattern:*"),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	time.Sleep(500 * time.Millisecond)
+
+	// the stream created after subscribed
+	_, err = execRedisCommand(client, "XADD gotestPattern:3 * name zoro age 21").Result()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	time.Sleep(500 * time.Millisecond)
+
+	// the stream deleted after subscribed
+	_, err = execRedisCommand(client, "DEL gotestPattern:1").Result()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	time.Sleep(500 * time.Millisecond)
+
+	// assert
+	{
+		var expectedMsgCnt int32 = 5
+		if got := atomic.LoadInt32(&msgCnt); got != expectedMsgCnt {
+			t.Errorf("expect %d messages, but got %d messages", expectedMsgCnt, got)
+		}
+	}
+	{
+		streamKeys := append([]string(nil), c.client.getStreamKeys()...)
+		sort.Strings(streamKeys)
+
+		var expectedStreamKeys = []string{"gotestPattern:2", "gotestPattern:3"}
+		if !reflect.DeepEqual(expectedStreamKeys, streamKeys) {
+			t.Errorf("consumerClient.streamKeys expect:: %v, got:: %v\n", expectedStreamKeys, streamKeys)
+		}
+	}
+}
+
+func TestConsumer_Subscribe_WithStreamPatternWithoutGroup(t *testing.T) {
+	client := redis.NewClient(&redis.Options{
+		Addr: __TEST_REDIS_SERVER,
+		DB:   0,
+	})
+	if client == nil {
+		panic("fail to create redis.Client")
+	}
+	defer client.Close()
+
+	{
+		/*
+			XGROUP CREATE gotestNoGroup:1 gotestGroup 0 MKSTREAM
+
+			XADD gotestNoGroup:1 * name luffy age 19
+			XADD gotestNoGroup:2 * name roger age ??
+
+			DEL gotestNoGroup:1
+			DEL gotestNoGroup:2
+			DEL gotestNoGroup:3
+		*/
+		for _, cmd := range []string{
+			"XGROUP CREATE gotestNoGroup:1 gotestGroup 0 MKSTREAM",
+
+			"XADD gotestNoGroup:1 * name luffy age 19",
+			"XADD gotestNoGroup:2 * name roger age ??",
+		} {
+			_, err := execRedisCommand(client, cmd).Result()
+			if err != nil {
+				panic(err)
+			}
+		}
+		defer func() {
+			for _, cmd := range []string{
+				"DEL gotestNoGroup:1",
+				"DEL gotestNoGroup:2",
+				"DEL gotestNoGroup:3",
+			} {
+				_, err := execRedisCommand(client, cmd).Result()
+				if err != nil {
+					panic(err)
+				}
+			}
+		}()
+	}
+
+	opt := redis.UniversalOptions{
+		Addrs: []string{__TEST_REDIS_SERVER},
+		DB:    0,
+	}
+
+	var (
+		msgCnt int32 = 0
+		errCnt int32 = 0
+	)
+
+	c := &Consumer{
+		Group:               "gotestGroup",
+		Name:                "gotestConsumer",
+		RedisOption:         &opt,
+		MaxInFlight:         8,
+		MaxPollingTimeout:   10 * time.Millisecond,
+		ClaimMinIdleTime:    5 * time.Second,
+		IdlingTimeout:       10 * time.Millisecond,
+		ClaimSensitivity:    8,
+		ClaimOccurrenceRate: 1,
+		GroupStartOffset:    StreamZeroID,
+		DiscoveryInterval:   100 * time.Millisecond,
+		MessageHandler: func(message *Message) {
+			log.Printf("Stream: %s, Message:%+v\n", message.Stream, message)
+			atomic.AddInt32(&msgCnt, 1)
+			message.Ack()
+		},
+		ErrorHandler: func(err error) (disposed bool) {
+			t.Logf("%+v", err)
+			atomic.AddInt32(&errCnt, 1)
+			return false
+		},
+	}
+
+	err := c.Subscribe(
+		StreamMatch("gotestNoGroup:*"),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	time.Sleep(300 * time.Millisecond)
+
+	// the stream without group created after subscribed
+	_, err = execRedisCommand(client, "XADD gotestNoGroup:3 * name zoro age 21").Result()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	time.Sleep(300 * time.Millisecond)
+
+	// the consumer keeps reading the streams with group
+	_, err = execRedisCommand(client, "XADD gotestNoGroup:1 * name nami age 21").Result()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	time.Sleep(300 * time.Millisecond)
+
+	{
+		streamKeys := c.client.getStreamKeys()
+
+		var expectedStreamKeys = []string{"gotestNoGroup:1"}
+		if !reflect.DeepEqual(expectedStreamKeys, streamKeys) {
+			t.Errorf("consumerClient.streamKeys expect:: %v, got:: %v\n", expectedStreamKeys, streamKeys)
+		}
+	}
+
+	// the stream is discovered once the group created
+	_, err = execRedisCommand(client, "XGROUP CREATE gotestNoGroup:2 gotestGroup 0").Result()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	time.Sleep(500 * time.Millisecond)
+
+	// assert
+	{
+		var expectedMsgCnt int32 = 3
+		if got := atomic.LoadInt32(&msgCnt); got != expectedMsgCnt {
+			t.Errorf("expect %d messages, but got %d messages", expectedMsgCnt, got)
+		}
+	}
+	{
+		var expectedErrCnt int32 = 0
+		if got := atomic.LoadInt32(&errCnt); got != expectedErrCnt {
+			t.Errorf("expect %d errors, but got %d errors", expectedErrCnt, got)
+		}
+	}
+	{
+		streamKeys := append([]string(nil), c.client.getStreamKeys()...)
+		sort.Strings(streamKeys)
+
+		var expectedStreamKeys = []string{"gotestNoGroup:1", "gotestNoGroup:2"}
+		if !reflect.DeepEqual(expectedStreamKeys, streamKeys) {
+			t.Errorf("consumerClient.streamKeys expect:: %v, got:: %v\n", expectedStreamKeys, streamKeys)
+		}
+	}
+}
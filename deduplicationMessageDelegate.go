@@ -0,0 +1,61 @@
+package redis
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+var (
+	_ MessageDelegate   = new(deduplicationMessageDelegate)
+	_ ackAndDelDelegate = new(deduplicationMessageDelegate)
+)
+
+// deduplicationMessageDelegate marks the idempotency key of the message as
+// processed before the message is acknowledged, so that the duplicates are
+// skipped once the message has been acknowledged.
+type deduplicationMessageDelegate struct {
+	delegate MessageDelegate
+	mark     func()
+}
+
+// OnAck implements MessageDelegate.
+func (d *deduplicationMessageDelegate) OnAck(msg *Message) {
+	// the message which has been responded, e.g. Nack(), is not marked
+	if atomic.LoadInt32(&msg.responded) == 1 {
+		return
+	}
+
+	d.mark()
+	d.delegate.OnAck(msg)
+}
+
+// OnDel implements MessageDelegate.
+func (d *deduplicationMessageDelegate) OnDel(msg *Message) {
+	d.delegate.OnDel(msg)
+}
+
+// OnNack implements MessageDelegate.
+func (d *deduplicationMessageDelegate) OnNack(msg *Message, delay time.Duration) {
+	d.delegate.OnNack(msg, delay)
+}
+
+// OnRetry implements MessageDelegate.
+func (d *deduplicationMessageDelegate) OnRetry(msg *Message) {
+	d.delegate.OnRetry(msg)
+}
+
+// OnAckAndDel implements ackAndDelDelegate.
+func (d *deduplicationMessageDelegate) OnAckAndDel(msg *Message) {
+	if atomic.LoadInt32(&msg.responded) == 1 {
+		d.delegate.OnDel(msg)
+		return
+	}
+
+	d.mark()
+	if delegate, ok := d.delegate.(ackAndDelDelegate); ok {
+		delegate.OnAckAndDel(msg)
+	} else {
+		d.delegate.OnAck(msg)
+		d.delegate.OnDel(msg)
+	}
+}
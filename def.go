@@ -1,8 +1,11 @@
 package redis
 
 import (
+	"context"
+	"errors"
 	"log"
 	"os"
+	"time"
 
 	redis "github.com/go-redis/redis/v7"
 )
@@ -16,17 +19,67 @@ const (
 	StreamNeverDeliveredOffset ConsumerOffset = ">"
 	StreamUnspecifiedOffset    ConsumerOffset = ""
 
+	BackpressureBlock BackpressurePolicy = 0
+	BackpressureDrop  BackpressurePolicy = 1
+	BackpressureError BackpressurePolicy = 2
+
+	CircuitClosed   CircuitState = 0
+	CircuitOpen     CircuitState = 1
+	CircuitHalfOpen CircuitState = 2
+
 	Nil = redis.Nil
 
 	LOGGER_PREFIX string = "[lib-redis-stream] "
+	METER_NAME    string = "github.com/Bofry/lib-redis-stream"
 
 	MAX_PENDING_FETCHING_SIZE         int64 = 4096
 	MIN_PENDING_FETCHING_SIZE         int64 = 16
 	PENDING_FETCHING_SIZE_COEFFICIENT int64 = 3
+
+	DEFAULT_ACK_FLUSH_INTERVAL = 100 * time.Millisecond
+
+	DEFAULT_STREAM_DISCOVERY_INTERVAL       = 30 * time.Second
+	DEFAULT_STREAM_SCAN_COUNT         int64 = 1000
+
+	DEFAULT_MONITOR_INTERVAL       = 10 * time.Second
+	MAX_LAG_SCAN_SIZE        int64 = 100000
+	LAG_SCAN_PAGE_SIZE       int64 = 1000
+
+	DEFAULT_ASYNC_QUEUE_SIZE  = 4096
+	DEFAULT_ASYNC_BATCH_SIZE  = 128
+	DEFAULT_ASYNC_LINGER_TIME = 5 * time.Millisecond
+
+	DEFAULT_IDEMPOTENCY_TTL = 24 * time.Hour
+
+	MESSAGE_STATE_DEAD_LETTER_ORIGIN_ID     = "dead-letter-origin-id"
+	MESSAGE_STATE_DEAD_LETTER_ORIGIN_STREAM = "dead-letter-origin-stream"
+	MESSAGE_STATE_DEAD_LETTER_ORIGIN_GROUP  = "dead-letter-origin-group"
+	MESSAGE_STATE_DEAD_LETTER_REASON        = "dead-letter-reason"
+	MESSAGE_STATE_IDEMPOTENCY_KEY           = "idempotency-key"
+	MESSAGE_STATE_CODEC                     = "codec"
+	MESSAGE_STATE_SCHEMA                    = "schema"
+
+	MESSAGE_PAYLOAD_KEY = "payload"
 )
 
 var (
+	ErrQueueFull           = errors.New("the queue of the AsyncProducer is full")
+	ErrCircuitOpen         = errors.New("the circuit breaker of the Producer is open")
+	ErrBatchIdempotencyKey = errors.New("the idempotency key cannot be applied to the whole batch, set it by BatchMessage.Options")
+
 	defaultLogger *log.Logger = log.New(os.Stdout, LOGGER_PREFIX, log.LstdFlags|log.Lmsgprefix)
+
+	defaultRetryBackoff BackoffPolicy = &ExponentialBackoff{
+		InitialInterval: 1 * time.Second,
+		MaxInterval:     5 * time.Minute,
+		Multiplier:      2,
+	}
+
+	defaultErrorBackoff BackoffPolicy = &ExponentialBackoff{
+		InitialInterval: 100 * time.Millisecond,
+		MaxInterval:     30 * time.Second,
+		Multiplier:      2,
+	}
 )
 
 type (
@@ -34,12 +87,29 @@ type (
 	UniversalClient  = redis.UniversalClient
 	XMessage         = redis.XMessage
 	XStream          = redis.XStream
+	XPending         = redis.XPending
+	XPendingExt      = redis.XPendingExt
+	XPendingExtArgs  = redis.XPendingExtArgs
 
 	ConsumerOffset string
 
+	BackpressurePolicy int
+
 	ProduceMessageOption interface {
 		applyContent(msg *MessageContent) error
 		applyID(id string) string
+		applyXAdd(setting *XAddSetting)
+	}
+
+	XAddSetting struct {
+		Retention      StreamRetention
+		NoMkStream     bool
+		IdempotencyKey string
+		IdempotencyTTL time.Duration
+	}
+
+	ProduceBatchSetting struct {
+		Atomic bool
 	}
 
 	StreamOffsetInfo interface {
@@ -49,6 +119,18 @@ type (
 	MessageDelegate interface {
 		OnAck(msg *Message)
 		OnDel(msg *Message)
+		OnNack(msg *Message, delay time.Duration)
+		OnRetry(msg *Message)
+	}
+
+	Codec interface {
+		Name() string
+		Marshal(v interface{}) ([]byte, error)
+		Unmarshal(data []byte, v interface{}) error
+	}
+
+	BackoffPolicy interface {
+		Backoff(attempt int64) time.Duration
 	}
 
 	RedisError interface {
@@ -66,8 +148,13 @@ type (
 
 // func
 type (
-	ErrorHandleProc   func(err error) (disposed bool)
-	MessageHandleProc func(message *Message)
+	ErrorHandleProc          func(err error) (disposed bool)
+	MessageHandleProc        func(message *Message)
+	ContextMessageHandleProc func(ctx context.Context, message *Message)
+	BatchMessageHandleProc   func(messages []*Message)
+	MessageMiddleware        func(next MessageHandleProc) MessageHandleProc
+	GroupLagHandleProc       func(lags []GroupLag)
+	DeliveryReportHandleProc func(report *DeliveryReport)
 )
 
 func DefaultLogger() *log.Logger {
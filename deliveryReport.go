@@ -0,0 +1,8 @@
+package redis
+
+type DeliveryReport struct {
+	Stream string
+	Values map[string]interface{}
+	ID     string // 寫入成功時的訊息 ID
+	Err    error  // 寫入失敗或被丟棄的原因
+}
@@ -0,0 +1,16 @@
+package redis
+
+// ErrorPolicy specifies how the Consumer reacts to the errors which are
+// not handled by the Consumer.ErrorHandler.
+type ErrorPolicy int
+
+const (
+	// ErrorPolicyStop stops the Consumer, the error can be retrieved by
+	// Consumer.Err() after Consumer.Done() is closed.
+	ErrorPolicyStop ErrorPolicy = iota
+	// ErrorPolicyRetry retries the transient errors with the
+	// Consumer.ErrorBackoff, and stops the Consumer on the others.
+	ErrorPolicyRetry
+	// ErrorPolicyExit logs the error and exits the process.
+	ErrorPolicyExit
+)
@@ -0,0 +1,27 @@
+package redis
+
+type GroupInfo struct {
+	Name            string
+	Consumers       int64
+	Pending         int64
+	LastDeliveredID string
+	EntriesRead     int64 // Redis 7.0 起提供, 無法取得時為 -1
+	Lag             int64 // Redis 7.0 起提供, 無法取得時為 -1
+}
+
+func parseGroupInfo(reply interface{}) (*GroupInfo, error) {
+	info, err := parseInfoReply(reply)
+	if err != nil {
+		return nil, err
+	}
+
+	v := &GroupInfo{
+		Name:            infoString(info, "name"),
+		LastDeliveredID: infoString(info, "last-delivered-id"),
+		EntriesRead:     infoInt64OrDefault(info, "entries-read", -1),
+		Lag:             infoInt64OrDefault(info, "lag", -1),
+	}
+	v.Consumers, _ = infoInt64(info, "consumers")
+	v.Pending, _ = infoInt64(info, "pending")
+	return v, nil
+}
@@ -0,0 +1,16 @@
+package redis
+
+import "time"
+
+type GroupLag struct {
+	Stream            string
+	Group             string
+	Length            int64            // stream 的訊息數
+	LastGeneratedID   string           // stream 最後產生的訊息 ID
+	LastDeliveredID   string           // group 最後遞送的訊息 ID
+	Lag               int64            // 尚未遞送給 group 的訊息數
+	LagTruncated      bool             // 自行計算 lag 時超過 MAX_LAG_SCAN_SIZE, Lag 僅計算至上限
+	Pending           int64            // 已遞送但尚未 XACK 的訊息數
+	OldestPendingIdle time.Duration    // 最早的 pending 訊息距離上次遞送的時間
+	ConsumerPending   map[string]int64 // 各 consumer 的 pending 訊息數
+}
@@ -0,0 +1,48 @@
+package redis
+
+import (
+	"strings"
+)
+
+// idempotentXAddScript adds the entry only if the idempotency key doesn't
+// exist, the ID of the original entry is replied otherwise.
+//
+//	KEYS[1]: the stream
+//	KEYS[2]: the idempotency key
+//	ARGV[1]: the TTL of the idempotency key in milliseconds
+//	ARGV[2:]: the arguments of XADD after the stream
+const idempotentXAddScript = `
+local id = redis.call('GET', KEYS[2])
+if id then
+	return id
+end
+id = redis.call('XADD', KEYS[1], unpack(ARGV, 2))
+if id then
+	redis.call('SET', KEYS[2], id, 'PX', ARGV[1])
+end
+return id
+`
+
+// producedKey returns the key which records the message ID of the
+// idempotency key, it is in the same hash slot with the stream.
+func producedKey(stream, key string) string {
+	return hashTagOf(stream) + ":idempotency:produced:" + key
+}
+
+// processedKey returns the key which marks the idempotency key has been
+// processed by the group.
+func processedKey(stream, group, key string) string {
+	return hashTagOf(stream) + ":idempotency:processed:" + group + ":" + key
+}
+
+// hashTagOf returns the key itself if it has a hash tag, or the key
+// wrapped in a hash tag, thus the derived keys share the hash slot of the
+// key in cluster mode.
+func hashTagOf(key string) string {
+	if start := strings.IndexByte(key, '{'); start >= 0 {
+		if end := strings.IndexByte(key[start+1:], '}'); end > 0 {
+			return key
+		}
+	}
+	return "{" + key + "}"
+}
@@ -0,0 +1,17 @@
+package redis
+
+import "testing"
+
+func TestHashTagOf(t *testing.T) {
+	cases := map[string]string{
+		"gotestStream1":       "{gotestStream1}",
+		"{orders}:gotest":     "{orders}:gotest",
+		"gotest:{orders}:log": "gotest:{orders}:log",
+		"gotest{}":            "{gotest{}}",
+	}
+	for key, expected := range cases {
+		if got := hashTagOf(key); expected != got {
+			t.Errorf("hashTagOf(%q) expect:: %v, got:: %v\n", key, expected, got)
+		}
+	}
+}
@@ -0,0 +1,22 @@
+package redis
+
+import "encoding/json"
+
+var _ Codec = JSONCodec{}
+
+type JSONCodec struct{}
+
+// Name implements Codec.
+func (JSONCodec) Name() string {
+	return "json"
+}
+
+// Marshal implements Codec.
+func (JSONCodec) Marshal(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+// Unmarshal implements Codec.
+func (JSONCodec) Unmarshal(data []byte, v interface{}) error {
+	return json.Unmarshal(data, v)
+}
@@ -1,9 +1,17 @@
 package redis
 
 import (
+	"context"
 	"sync/atomic"
+	"time"
 )
 
+// ackAndDelDelegate is an optional interface of MessageDelegate which
+// acknowledges and deletes the message at once.
+type ackAndDelDelegate interface {
+	OnAckAndDel(msg *Message)
+}
+
 type Message struct {
 	*XMessage
 
@@ -11,6 +19,8 @@ type Message struct {
 	Stream        string
 	Delegate      MessageDelegate
 
+	ctx context.Context
+
 	responded int32
 	killed    int32
 }
@@ -23,11 +33,43 @@ func (m *Message) Del() {
 	m.Delegate.OnDel(m)
 }
 
+// AckAndDel acknowledges and deletes the message at once.
+func (m *Message) AckAndDel() {
+	if d, ok := m.Delegate.(ackAndDelDelegate); ok {
+		d.OnAckAndDel(m)
+		return
+	}
+	m.Ack()
+	m.Del()
+}
+
+// Nack gives up the message and redelivers it after the delay. The message
+// is moved to the DeadLetterStream instead if it has been delivered
+// MaxDeliveryCount times.
+func (m *Message) Nack(delay time.Duration) {
+	m.Delegate.OnNack(m, delay)
+}
+
+// Retry redelivers the message after the delay computed by the
+// backoff policy of the Consumer.
+func (m *Message) Retry() {
+	m.Delegate.OnRetry(m)
+}
+
 func (m *Message) HasResponded() bool {
 	return atomic.LoadInt32(&m.responded) == 1 ||
 		atomic.LoadInt32(&m.killed) == 1
 }
 
+// Context returns the context of the Consumer which delivered the message.
+// The context is canceled when the Consumer stops.
+func (m *Message) Context() context.Context {
+	if m.ctx != nil {
+		return m.ctx
+	}
+	return context.Background()
+}
+
 func (m *Message) Content(opts ...DecodeMessageContentOption) *MessageContent {
 	content := DecodeMessageContent(m.Values, opts...)
 	if content != nil {
@@ -50,3 +92,8 @@ func (m *Message) canAck() bool {
 func (m *Message) canDel() bool {
 	return atomic.CompareAndSwapInt32(&m.killed, 0, 1)
 }
+
+// Decode decodes the payload written by Producer.WriteObject() into the v.
+func (m *Message) Decode(v interface{}, opts ...DecodeMessageContentOption) error {
+	return m.Content(opts...).Decode(v)
+}
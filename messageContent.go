@@ -1,6 +1,9 @@
 package redis
 
-import "strings"
+import (
+	"fmt"
+	"strings"
+)
 
 const (
 	_DefaultMessageStateKeyPrefix = "header:"
@@ -37,6 +40,34 @@ func (c *MessageContent) WriteTo(container map[string]interface{}) {
 	}
 }
 
+// validate checks the MessageState values respect MESSAGE_STATE_VALUE_MAX_SIZE
+// and the payload keys don't collide with the MessageState keys.
+func (c *MessageContent) validate() error {
+	var prefix = c.State.contentKeyPrefix
+	if prefix == "" {
+		prefix = _DefaultMessageStateKeyPrefix
+	}
+
+	var err error
+	c.State.Visit(func(name string, value interface{}) {
+		if err == nil {
+			if e := c.State.validateValue(value); e != nil {
+				err = fmt.Errorf("invalid MessageState '%s': %w", name, e)
+			}
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	for k := range c.Values {
+		if strings.HasPrefix(k, prefix) {
+			return fmt.Errorf("payload key '%s' collides with the MessageState key prefix '%s'", k, prefix)
+		}
+	}
+	return nil
+}
+
 func DecodeMessageContent(container map[string]interface{}, opts ...DecodeMessageContentOption) *MessageContent {
 	var (
 		content MessageContent = MessageContent{}
@@ -2,6 +2,7 @@ package redis
 
 import (
 	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -187,3 +188,44 @@ func TestMessageContent_Sanity(t *testing.T) {
 		}
 	}
 }
+
+func TestMessageContent_Validate(t *testing.T) {
+	msg := NewMessageContent()
+	msg.Values["name"] = "luffy"
+	_, err := msg.State.Set("correlation-id", "order-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := msg.validate(); err != nil {
+		t.Errorf("MessageContent.validate() expect:: %v, got:: %v\n", nil, err)
+	}
+
+	msg.Values["header:correlation-id"] = "order-2"
+	if err := msg.validate(); err == nil {
+		t.Errorf("MessageContent.validate() should return error with colliding payload key")
+	}
+	delete(msg.Values, "header:correlation-id")
+
+	_, err = msg.State.Set("token", strings.Repeat("x", MESSAGE_STATE_VALUE_MAX_SIZE+1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := msg.validate(); err == nil {
+		t.Errorf("MessageContent.validate() should return error with too large MessageState value")
+	}
+}
+
+func TestDecodeMessageContent_WithLargeMessageState(t *testing.T) {
+	var large = strings.Repeat("x", MESSAGE_STATE_VALUE_MAX_SIZE+1)
+
+	content := DecodeMessageContent(map[string]interface{}{
+		"header:big": large,
+		"name":       "luffy",
+	})
+	if !content.State.Has("big") {
+		t.Errorf("MessageState.Has(big) should be true")
+	}
+	if v := content.State.Value("big"); large != v {
+		t.Errorf("MessageState[big] expect:: %d bytes, got:: %v\n", len(large), v)
+	}
+}
@@ -0,0 +1,134 @@
+package redis
+
+import (
+	"context"
+	"log"
+	"runtime/debug"
+	"time"
+)
+
+const _DeduplicationMarkTimeout = 3 * time.Second
+
+// ChainMiddleware composes the middlewares into one, the first one is the
+// outermost.
+func ChainMiddleware(middlewares ...MessageMiddleware) MessageMiddleware {
+	return func(next MessageHandleProc) MessageHandleProc {
+		for i := len(middlewares) - 1; i >= 0; i-- {
+			next = middlewares[i](next)
+		}
+		return next
+	}
+}
+
+// LoggingMiddleware logs the stream, the message ID and the elapsed time of
+// each message.
+func LoggingMiddleware(logger *log.Logger) MessageMiddleware {
+	if logger == nil {
+		logger = defaultLogger
+	}
+
+	return func(next MessageHandleProc) MessageHandleProc {
+		return func(msg *Message) {
+			start := time.Now()
+			next(msg)
+			logger.Printf("Stream: %s, ID: %s, Elapsed: %v, Responded: %v\n",
+				msg.Stream, msg.ID, time.Since(start), msg.HasResponded())
+		}
+	}
+}
+
+// RecoveryMiddleware recovers the panic of the next handler and reports it
+// as a *ConsumerError to the errorHandler.
+func RecoveryMiddleware(errorHandler ErrorHandleProc) MessageMiddleware {
+	return func(next MessageHandleProc) MessageHandleProc {
+		return func(msg *Message) {
+			defer func() {
+				if r := recover(); r != nil {
+					err := newPanicConsumerError(r, debug.Stack(), msg)
+					if errorHandler != nil {
+						errorHandler(err)
+					}
+				}
+			}()
+			next(msg)
+		}
+	}
+}
+
+// TimeoutMiddleware sets the deadline of Message.Context() for the next
+// handler. The handler should return once the context is done.
+func TimeoutMiddleware(timeout time.Duration) MessageMiddleware {
+	return func(next MessageHandleProc) MessageHandleProc {
+		return func(msg *Message) {
+			parent := msg.ctx
+			ctx, cancel := context.WithTimeout(msg.Context(), timeout)
+			defer func() {
+				cancel()
+				msg.ctx = parent
+			}()
+
+			msg.ctx = ctx
+			next(msg)
+		}
+	}
+}
+
+// MetricsMiddleware reports the elapsed time of each message to the observe.
+func MetricsMiddleware(observe func(msg *Message, elapsed time.Duration)) MessageMiddleware {
+	return func(next MessageHandleProc) MessageHandleProc {
+		return func(msg *Message) {
+			start := time.Now()
+			defer func() {
+				observe(msg, time.Since(start))
+			}()
+			next(msg)
+		}
+	}
+}
+
+// DeduplicationMiddleware acknowledges and skips the messages whose
+// idempotency key, written by WithIdempotencyKey, has been processed by the
+// group. The key is marked as processed for the ttl when the message is
+// acknowledged, even if the context of the message is done. The message is processed if Redis fails to check the key,
+// and the failure of marking the key is logged.
+func DeduplicationMiddleware(client UniversalClient, ttl time.Duration, opts ...DecodeMessageContentOption) MessageMiddleware {
+	if ttl <= 0 {
+		ttl = DEFAULT_IDEMPOTENCY_TTL
+	}
+
+	return func(next MessageHandleProc) MessageHandleProc {
+		return func(msg *Message) {
+			key, _ := msg.Content(opts...).State.Value(MESSAGE_STATE_IDEMPOTENCY_KEY).(string)
+			if len(key) == 0 {
+				next(msg)
+				return
+			}
+
+			var (
+				marker = processedKey(msg.Stream, msg.ConsumerGroup, key)
+				handle = withContext(client, msg.Context())
+			)
+			if n, err := handle.Exists(marker).Result(); err == nil && n > 0 {
+				msg.Ack()
+				return
+			}
+
+			msg.Delegate = &deduplicationMessageDelegate{
+				delegate: msg.Delegate,
+				mark: func() {
+					// NOTE: the marker is written even if the Consumer is
+					// stopping, otherwise the acknowledged message would be
+					// processed again by its duplicates.
+					ctx, cancel := context.WithTimeout(context.Background(), _DeduplicationMarkTimeout)
+					defer cancel()
+
+					err := withContext(client, ctx).Set(marker, msg.ID, ttl).Err()
+					if err != nil {
+						defaultLogger.Printf("error sending command SET '%s' '%s' PX %d: %v", marker, msg.ID, ttl.Milliseconds(), err)
+					}
+				},
+			}
+			next(msg)
+		}
+	}
+}
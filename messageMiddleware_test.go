@@ -0,0 +1,215 @@
+package redis
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	redis "github.com/go-redis/redis/v7"
+)
+
+func TestRecoveryMiddleware(t *testing.T) {
+	var consumerErr *ConsumerError
+
+	handler := RecoveryMiddleware(func(err error) (disposed bool) {
+		consumerErr, _ = err.(*ConsumerError)
+		return true
+	})(func(message *Message) {
+		panic("boom")
+	})
+
+	handler(&Message{
+		Stream:   "gotestStream1",
+		XMessage: &redis.XMessage{ID: "1000-0"},
+	})
+
+	if consumerErr == nil {
+		t.Fatalf("RecoveryMiddleware should report *ConsumerError")
+	}
+	if !consumerErr.IsPanic() {
+		t.Errorf("ConsumerError.IsPanic() expect:: %v, got:: %v\n", true, consumerErr.IsPanic())
+	}
+	var expectedMessageID string = "1000-0"
+	if expectedMessageID != consumerErr.MessageID() {
+		t.Errorf("ConsumerError.MessageID() expect:: %v, got:: %v\n", expectedMessageID, consumerErr.MessageID())
+	}
+}
+
+func TestTimeoutMiddleware(t *testing.T) {
+	var deadline time.Time
+
+	msg := &Message{
+		Stream:   "gotestStream1",
+		XMessage: &redis.XMessage{ID: "1000-0"},
+	}
+
+	handler := TimeoutMiddleware(time.Second)(func(message *Message) {
+		deadline, _ = message.Context().Deadline()
+	})
+	handler(msg)
+
+	if deadline.IsZero() {
+		t.Errorf("TimeoutMiddleware should set the deadline of Message.Context()")
+	}
+	if _, ok := msg.Context().Deadline(); ok {
+		t.Errorf("TimeoutMiddleware should restore Message.Context() after handled")
+	}
+}
+
+func TestMetricsMiddleware(t *testing.T) {
+	var (
+		observed int
+		elapsed  time.Duration
+	)
+
+	handler := ChainMiddleware(
+		MetricsMiddleware(func(msg *Message, d time.Duration) {
+			observed++
+			elapsed = d
+		}),
+	)(func(message *Message) {
+		time.Sleep(10 * time.Millisecond)
+	})
+
+	handler(&Message{
+		Stream:   "gotestStream1",
+		XMessage: &redis.XMessage{ID: "1000-0"},
+	})
+
+	var expectedObserved int = 1
+	if expectedObserved != observed {
+		t.Errorf("MetricsMiddleware observed expect:: %v, got:: %v\n", expectedObserved, observed)
+	}
+	if elapsed < 10*time.Millisecond {
+		t.Errorf("MetricsMiddleware elapsed should be at least %v, got:: %v\n", 10*time.Millisecond, elapsed)
+	}
+}
+
+func TestDeduplicationMiddleware(t *testing.T) {
+	/*
+		DEL {gotestStream1}:idempotency:processed:gotestGroup:order-1
+	*/
+	client := redis.NewClient(&redis.Options{
+		Addr: __TEST_REDIS_SERVER,
+		DB:   0,
+	})
+	if client == nil {
+		panic("fail to create redis.Client")
+	}
+	defer client.Close()
+	defer client.Del(processedKey("gotestStream1", "gotestGroup", "order-1"))
+
+	var handled int
+
+	handler := DeduplicationMiddleware(client, time.Minute)(func(message *Message) {
+		handled++
+		message.Ack()
+	})
+
+	d := new(mockMessageDelegate)
+	for _, id := range []string{"1000-0", "1000-1"} {
+		handler(&Message{
+			Stream:        "gotestStream1",
+			ConsumerGroup: "gotestGroup",
+			Delegate:      d,
+			XMessage: &redis.XMessage{
+				ID: id,
+				Values: map[string]interface{}{
+					"header:idempotency-key": "order-1",
+					"name":                   "luffy",
+				},
+			},
+		})
+	}
+	// the message without idempotency key
+	handler(&Message{
+		Stream:        "gotestStream1",
+		ConsumerGroup: "gotestGroup",
+		Delegate:      d,
+		XMessage: &redis.XMessage{
+			ID:     "1000-2",
+			Values: map[string]interface{}{"name": "nami"},
+		},
+	})
+
+	var expectedHandled int = 2
+	if expectedHandled != handled {
+		t.Errorf("handled messages expect:: %v, got:: %v\n", expectedHandled, handled)
+	}
+	// the duplicate is acknowledged without handling
+	var expectedAckCalledCount int = 3
+	if expectedAckCalledCount != d.ackCalledCount {
+		t.Errorf("mockMessageDelegate.ackCalledCount expect:: %v, got:: %v\n", expectedAckCalledCount, d.ackCalledCount)
+	}
+
+	id, err := client.Get(processedKey("gotestStream1", "gotestGroup", "order-1")).Result()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var expectedID string = "1000-0"
+	if expectedID != id {
+		t.Errorf("processed key expect:: %v, got:: %v\n", expectedID, id)
+	}
+}
+
+func TestDeduplicationMiddleware_WithCanceledContext(t *testing.T) {
+	client := redis.NewClient(&redis.Options{
+		Addr: __TEST_REDIS_SERVER,
+		DB:   0,
+	})
+	if client == nil {
+		panic("fail to create redis.Client")
+	}
+	defer client.Close()
+	defer client.Del(processedKey("gotestStream1", "gotestGroup", "order-2"))
+
+	var buf bytes.Buffer
+	defaultLogger.SetOutput(&buf)
+	defer defaultLogger.SetOutput(os.Stdout)
+
+	var handled int
+
+	handler := DeduplicationMiddleware(client, time.Minute)(func(message *Message) {
+		handled++
+		message.Ack()
+	})
+
+	// the context is canceled, e.g. the Consumer is stopping
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	handler(&Message{
+		Stream:        "gotestStream1",
+		ConsumerGroup: "gotestGroup",
+		Delegate:      new(mockMessageDelegate),
+		XMessage: &redis.XMessage{
+			ID: "1000-0",
+			Values: map[string]interface{}{
+				"header:idempotency-key": "order-2",
+				"name":                   "luffy",
+			},
+		},
+		ctx: ctx,
+	})
+
+	// the message is processed even if Redis fails to check the key, and
+	// the key is marked when the message is acknowledged
+	var expectedHandled int = 1
+	if expectedHandled != handled {
+		t.Errorf("handled expect:: %v, got:: %v\n", expectedHandled, handled)
+	}
+	id, err := client.Get(processedKey("gotestStream1", "gotestGroup", "order-2")).Result()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var expectedID string = "1000-0"
+	if expectedID != id {
+		t.Errorf("processed key expect:: %v, got:: %v\n", expectedID, id)
+	}
+	if strings.Contains(buf.String(), "error sending command SET") {
+		t.Errorf("the key should be marked, got:: %q\n", buf.String())
+	}
+}
@@ -0,0 +1,22 @@
+package redis
+
+import "github.com/vmihailenco/msgpack/v5"
+
+var _ Codec = MessagePackCodec{}
+
+type MessagePackCodec struct{}
+
+// Name implements Codec.
+func (MessagePackCodec) Name() string {
+	return "msgpack"
+}
+
+// Marshal implements Codec.
+func (MessagePackCodec) Marshal(v interface{}) ([]byte, error) {
+	return msgpack.Marshal(v)
+}
+
+// Unmarshal implements Codec.
+func (MessagePackCodec) Unmarshal(data []byte, v interface{}) error {
+	return msgpack.Unmarshal(data, v)
+}
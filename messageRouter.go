@@ -0,0 +1,102 @@
+package redis
+
+import "sync"
+
+type messageRoute struct {
+	pattern string
+	handler MessageHandleProc
+}
+
+// MessageRouter dispatches the messages to the handlers by the stream name.
+// The exact stream name takes precedence over the patterns, and the patterns
+// are matched in the order they were added.
+type MessageRouter struct {
+	routes      map[string]MessageHandleProc
+	patterns    []messageRoute
+	fallback    MessageHandleProc
+	middlewares []MessageMiddleware
+
+	handler MessageHandleProc
+	mutex   sync.RWMutex
+}
+
+func NewMessageRouter() *MessageRouter {
+	r := &MessageRouter{
+		routes: make(map[string]MessageHandleProc),
+	}
+	r.handler = r.route
+	return r
+}
+
+func (r *MessageRouter) Add(stream string, handler MessageHandleProc) *MessageRouter {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.routes[stream] = handler
+	return r
+}
+
+// AddPattern routes the messages of the streams which match the glob-style
+// pattern, e.g. "orders:*".
+func (r *MessageRouter) AddPattern(pattern string, handler MessageHandleProc) *MessageRouter {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.patterns = append(r.patterns, messageRoute{
+		pattern: pattern,
+		handler: handler,
+	})
+	return r
+}
+
+// Fallback handles the messages which match none of the routes. The
+// messages are left pending if the fallback is not set.
+func (r *MessageRouter) Fallback(handler MessageHandleProc) *MessageRouter {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.fallback = handler
+	return r
+}
+
+// Use appends the middlewares which wrap all the routes, the first one is
+// the outermost.
+func (r *MessageRouter) Use(middlewares ...MessageMiddleware) *MessageRouter {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.middlewares = append(r.middlewares, middlewares...)
+	r.handler = ChainMiddleware(r.middlewares...)(r.route)
+	return r
+}
+
+// ProcessMessage implements MessageHandleProc.
+func (r *MessageRouter) ProcessMessage(msg *Message) {
+	r.mutex.RLock()
+	handler := r.handler
+	r.mutex.RUnlock()
+
+	handler(msg)
+}
+
+func (r *MessageRouter) route(msg *Message) {
+	handler := r.lookup(msg.Stream)
+	if handler != nil {
+		handler(msg)
+	}
+}
+
+func (r *MessageRouter) lookup(stream string) MessageHandleProc {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	if handler, ok := r.routes[stream]; ok {
+		return handler
+	}
+	for _, route := range r.patterns {
+		if matchPattern(route.pattern, stream) {
+			return route.handler
+		}
+	}
+	return r.fallback
+}
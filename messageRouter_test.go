@@ -0,0 +1,79 @@
+package redis
+
+import (
+	"reflect"
+	"testing"
+
+	redis "github.com/go-redis/redis/v7"
+)
+
+func TestMessageRouter(t *testing.T) {
+	var routed []string
+
+	router := NewMessageRouter().
+		Add("orders:tw", func(message *Message) {
+			routed = append(routed, "orders:tw")
+		}).
+		AddPattern("orders:*", func(message *Message) {
+			routed = append(routed, "orders:*")
+		}).
+		AddPattern("*", func(message *Message) {
+			routed = append(routed, "*")
+		})
+
+	for _, stream := range []string{"orders:tw", "orders:jp", "payments:tw"} {
+		router.ProcessMessage(&Message{
+			Stream:   stream,
+			XMessage: &redis.XMessage{ID: "1000-0"},
+		})
+	}
+
+	var expectedRouted = []string{"orders:tw", "orders:*", "*"}
+	if !reflect.DeepEqual(expectedRouted, routed) {
+		t.Errorf("MessageRouter routed expect:: %v, got:: %v\n", expectedRouted, routed)
+	}
+}
+
+func TestMessageRouter_WithFallbackAndMiddleware(t *testing.T) {
+	var routed []string
+
+	tag := func(name string) MessageMiddleware {
+		return func(next MessageHandleProc) MessageHandleProc {
+			return func(message *Message) {
+				routed = append(routed, name)
+				next(message)
+			}
+		}
+	}
+
+	router := NewMessageRouter().
+		Add("orders:tw", func(message *Message) {
+			routed = append(routed, "orders:tw")
+		})
+
+	router.ProcessMessage(&Message{
+		Stream:   "payments:tw",
+		XMessage: &redis.XMessage{ID: "1000-0"},
+	})
+	if len(routed) != 0 {
+		t.Errorf("MessageRouter should drop the unrouted message without fallback, got:: %v\n", routed)
+	}
+
+	router.
+		Fallback(func(message *Message) {
+			routed = append(routed, "fallback")
+		}).
+		Use(tag("outer"), tag("inner"))
+
+	for _, stream := range []string{"orders:tw", "payments:tw"} {
+		router.ProcessMessage(&Message{
+			Stream:   stream,
+			XMessage: &redis.XMessage{ID: "1000-0"},
+		})
+	}
+
+	var expectedRouted = []string{"outer", "inner", "orders:tw", "outer", "inner", "fallback"}
+	if !reflect.DeepEqual(expectedRouted, routed) {
+		t.Errorf("MessageRouter routed expect:: %v, got:: %v\n", expectedRouted, routed)
+	}
+}
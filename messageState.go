@@ -120,6 +120,18 @@ func (s *MessageState) validateName(name string) error {
 	return nil
 }
 
+// validateValue checks the value respects MESSAGE_STATE_VALUE_MAX_SIZE, it
+// is checked on producing only so that the received values are kept.
+func (s *MessageState) validateValue(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+	if size := sizeOf(value); size > MESSAGE_STATE_VALUE_MAX_SIZE {
+		return fmt.Errorf("specified value is too large (size: %d, max size: %d)", size, MESSAGE_STATE_VALUE_MAX_SIZE)
+	}
+	return nil
+}
+
 func (s *MessageState) isValidNameChar(ch byte) bool {
 	if ch == '_' || ch == '-' ||
 		(ch >= 'a' && ch <= 'z') ||
@@ -0,0 +1,137 @@
+package redis
+
+import (
+	"context"
+	"strings"
+
+	"github.com/Bofry/lib-redis-stream/tracing"
+	"github.com/Bofry/trace"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.20.0"
+	oteltrace "go.opentelemetry.io/otel/trace"
+)
+
+var messagingConsumerGroupKey = attribute.Key("messaging.consumer.group.name")
+
+// extractMessageTraceContext extracts the trace context injected by
+// WithTracePropagation from the MessageState of the message.
+func extractMessageTraceContext(msg *Message, propagator propagation.TextMapPropagator, keyPrefix string) context.Context {
+	var ctx = msg.Context()
+
+	if propagator == nil {
+		propagator = trace.GetTextMapPropagator()
+	}
+
+	var opts []DecodeMessageContentOption
+	if len(keyPrefix) > 0 {
+		opts = append(opts, WithMessageStateKeyPrefix(keyPrefix))
+	}
+	content := msg.Content(opts...)
+	if content == nil {
+		return ctx
+	}
+
+	carrier := tracing.NewMessageStateCarrier(&content.State)
+	return propagator.Extract(ctx, carrier)
+}
+
+// startMessageSpan starts a consumer span as the child of the producer span
+// which is extracted into Message.Context(), and replaces Message.Context()
+// with the span. It returns a non-recording span if the tracer is nil.
+func startMessageSpan(tracer oteltrace.Tracer, msg *Message) oteltrace.Span {
+	if tracer == nil {
+		return oteltrace.SpanFromContext(context.Background())
+	}
+
+	var opts = []oteltrace.SpanStartOption{
+		oteltrace.WithSpanKind(oteltrace.SpanKindConsumer),
+		oteltrace.WithAttributes(
+			semconv.MessagingSystem("redis"),
+			semconv.MessagingOperationProcess,
+			semconv.MessagingDestinationName(msg.Stream),
+			semconv.MessagingMessageID(msg.ID),
+			messagingConsumerGroupKey.String(msg.ConsumerGroup),
+		),
+	}
+
+	producerSpanContext := oteltrace.SpanContextFromContext(msg.Context())
+	if producerSpanContext.IsValid() {
+		opts = append(opts, oteltrace.WithLinks(oteltrace.Link{
+			SpanContext: producerSpanContext,
+		}))
+	}
+
+	ctx, span := tracer.Start(msg.Context(), msg.Stream+" process", opts...)
+	msg.ctx = ctx
+	return span
+}
+
+func recordSpanError(span oteltrace.Span, err error) {
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
+
+// startProducerSpan starts a producer span for writing the message to
+// the stream.
+func startProducerSpan(tracer oteltrace.Tracer, ctx context.Context, stream string) (context.Context, oteltrace.Span) {
+	return tracer.Start(ctx, stream+" publish",
+		oteltrace.WithSpanKind(oteltrace.SpanKindProducer),
+		oteltrace.WithAttributes(
+			semconv.MessagingSystem("redis"),
+			semconv.MessagingOperationPublish,
+			semconv.MessagingDestinationName(stream),
+		),
+	)
+}
+
+func endProducerSpan(span oteltrace.Span, id string, values map[string]interface{}, err error) {
+	span.SetAttributes(semconv.MessagingMessagePayloadSizeBytes(computePayloadSize(values)))
+	if err != nil {
+		recordSpanError(span, err)
+		return
+	}
+	span.SetAttributes(semconv.MessagingMessageID(id))
+}
+
+func computePayloadSize(values map[string]interface{}) int {
+	var size int
+	for k, v := range values {
+		size += len(k) + sizeOf(v)
+	}
+	return size
+}
+
+var _ propagation.TextMapCarrier = new(messageValuesCarrier)
+
+// messageValuesCarrier injects the trace context into the encoded message
+// values as the MessageState does.
+type messageValuesCarrier struct {
+	values map[string]interface{}
+	prefix string
+}
+
+// Get implements propagation.TextMapCarrier.
+func (c *messageValuesCarrier) Get(key string) string {
+	if v, ok := c.values[c.prefix+key].(string); ok {
+		return v
+	}
+	return ""
+}
+
+// Set implements propagation.TextMapCarrier.
+func (c *messageValuesCarrier) Set(key string, value string) {
+	c.values[c.prefix+key] = value
+}
+
+// Keys implements propagation.TextMapCarrier.
+func (c *messageValuesCarrier) Keys() []string {
+	var keys []string
+	for k := range c.values {
+		if name, ok := strings.CutPrefix(k, c.prefix); ok {
+			keys = append(keys, name)
+		}
+	}
+	return keys
+}
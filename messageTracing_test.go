@@ -0,0 +1,85 @@
+package redis
+
+import (
+	"context"
+	"testing"
+
+	redis "github.com/go-redis/redis/v7"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestConsumer_WithTracer(t *testing.T) {
+	var (
+		recorder = tracetest.NewSpanRecorder()
+		provider = sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	)
+	defer provider.Shutdown(context.Background())
+
+	var (
+		producerTraceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+		producerSpanID  = "00f067aa0ba902b7"
+
+		handledSpanContext trace.SpanContext
+	)
+
+	c := &Consumer{
+		Group:             "gotestGroup",
+		Name:              "gotestConsumer",
+		Tracer:            provider.Tracer("gotest"),
+		TextMapPropagator: propagation.TraceContext{},
+		MessageHandler: func(message *Message) {
+			handledSpanContext = trace.SpanContextFromContext(message.Context())
+		},
+	}
+
+	c.handleMessage("gotestStream1", &redis.XMessage{
+		ID: "1000-0",
+		Values: map[string]interface{}{
+			"header:traceparent": "00-" + producerTraceID + "-" + producerSpanID + "-01",
+			"name":               "luffy",
+		},
+	})
+
+	spans := recorder.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("expect %d spans, but got %d spans", 1, len(spans))
+	}
+	span := spans[0]
+
+	if span.SpanKind() != trace.SpanKindConsumer {
+		t.Errorf("span.SpanKind() expect:: %v, got:: %v\n", trace.SpanKindConsumer, span.SpanKind())
+	}
+	if span.Parent().TraceID().String() != producerTraceID {
+		t.Errorf("span.Parent().TraceID() expect:: %v, got:: %v\n", producerTraceID, span.Parent().TraceID())
+	}
+	if span.Parent().SpanID().String() != producerSpanID {
+		t.Errorf("span.Parent().SpanID() expect:: %v, got:: %v\n", producerSpanID, span.Parent().SpanID())
+	}
+	if len(span.Links()) != 1 || span.Links()[0].SpanContext.SpanID().String() != producerSpanID {
+		t.Errorf("span.Links() should link to the producer span, got:: %v\n", span.Links())
+	}
+	if handledSpanContext.SpanID() != span.SpanContext().SpanID() {
+		t.Errorf("Message.Context() span expect:: %v, got:: %v\n", span.SpanContext().SpanID(), handledSpanContext.SpanID())
+	}
+
+	var attrs = make(map[attribute.Key]string)
+	for _, kv := range span.Attributes() {
+		attrs[kv.Key] = kv.Value.Emit()
+	}
+	expectedAttrs := map[attribute.Key]string{
+		"messaging.system":              "redis",
+		"messaging.operation":           "process",
+		"messaging.destination.name":    "gotestStream1",
+		"messaging.message.id":          "1000-0",
+		"messaging.consumer.group.name": "gotestGroup",
+	}
+	for k, v := range expectedAttrs {
+		if attrs[k] != v {
+			t.Errorf("span attribute %s expect:: %v, got:: %v\n", k, v, attrs[k])
+		}
+	}
+}
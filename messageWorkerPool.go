@@ -0,0 +1,78 @@
+package redis
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+)
+
+type messageWorkerPool struct {
+	size    int
+	handler func(msg *Message)
+
+	queue    chan *Message
+	released chan struct{}
+	inFlight int64
+
+	wg sync.WaitGroup
+}
+
+func newMessageWorkerPool(size int, capacity int64, handler func(msg *Message)) *messageWorkerPool {
+	if capacity < int64(size) {
+		capacity = int64(size)
+	}
+
+	return &messageWorkerPool{
+		size:     size,
+		handler:  handler,
+		queue:    make(chan *Message, capacity),
+		released: make(chan struct{}, 1),
+	}
+}
+
+func (p *messageWorkerPool) start() {
+	for i := 0; i < p.size; i++ {
+		p.wg.Add(1)
+		go func() {
+			defer p.wg.Done()
+
+			for msg := range p.queue {
+				p.handler(msg)
+				p.release()
+			}
+		}()
+	}
+}
+
+func (p *messageWorkerPool) dispatch(msg *Message) {
+	atomic.AddInt64(&p.inFlight, 1)
+	p.queue <- msg
+}
+
+func (p *messageWorkerPool) countInFlight() int64 {
+	return atomic.LoadInt64(&p.inFlight)
+}
+
+// wait blocks until any in-flight message has been released, ctx is
+// done or the stop channel is signaled.
+func (p *messageWorkerPool) wait(ctx context.Context, stop <-chan bool) {
+	select {
+	case <-p.released:
+	case <-ctx.Done():
+	case <-stop:
+	}
+}
+
+func (p *messageWorkerPool) close() {
+	close(p.queue)
+	p.wg.Wait()
+}
+
+func (p *messageWorkerPool) release() {
+	atomic.AddInt64(&p.inFlight, -1)
+
+	select {
+	case p.released <- struct{}{}:
+	default:
+	}
+}
@@ -0,0 +1,78 @@
+package redis
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestMessageWorkerPool(t *testing.T) {
+	var (
+		handledCount   int32
+		concurrent     int32
+		maxConcurrent  int32
+		concurrentLock sync.Mutex
+	)
+
+	pool := newMessageWorkerPool(3, 8, func(msg *Message) {
+		n := atomic.AddInt32(&concurrent, 1)
+		concurrentLock.Lock()
+		if n > maxConcurrent {
+			maxConcurrent = n
+		}
+		concurrentLock.Unlock()
+
+		time.Sleep(50 * time.Millisecond)
+		atomic.AddInt32(&concurrent, -1)
+		atomic.AddInt32(&handledCount, 1)
+	})
+	pool.start()
+
+	for i := 0; i < 8; i++ {
+		pool.dispatch(&Message{})
+	}
+
+	{
+		var expectedInFlight int64 = 8
+		if expectedInFlight != pool.countInFlight() {
+			t.Errorf("messageWorkerPool.countInFlight() expect:: %v, got:: %v\n", expectedInFlight, pool.countInFlight())
+		}
+	}
+
+	pool.close()
+
+	{
+		var expectedHandledCount int32 = 8
+		if expectedHandledCount != handledCount {
+			t.Errorf("handledCount expect:: %v, got:: %v\n", expectedHandledCount, handledCount)
+		}
+		var expectedMaxConcurrent int32 = 3
+		if expectedMaxConcurrent != maxConcurrent {
+			t.Errorf("maxConcurrent expect:: %v, got:: %v\n", expectedMaxConcurrent, maxConcurrent)
+		}
+		var expectedInFlight int64 = 0
+		if expectedInFlight != pool.countInFlight() {
+			t.Errorf("messageWorkerPool.countInFlight() expect:: %v, got:: %v\n", expectedInFlight, pool.countInFlight())
+		}
+	}
+}
+
+func TestMessageWorkerPool_Wait(t *testing.T) {
+	pool := newMessageWorkerPool(1, 1, func(msg *Message) {
+		time.Sleep(20 * time.Millisecond)
+	})
+	pool.start()
+	defer pool.close()
+
+	pool.dispatch(&Message{})
+
+	stop := make(chan bool)
+	pool.wait(context.Background(), stop)
+
+	var expectedInFlight int64 = 0
+	if expectedInFlight != pool.countInFlight() {
+		t.Errorf("messageWorkerPool.countInFlight() expect:: %v, got:: %v\n", expectedInFlight, pool.countInFlight())
+	}
+}
@@ -1,7 +1,9 @@
 package redis
 
 import (
+	"context"
 	"testing"
+	"time"
 
 	redis "github.com/go-redis/redis/v7"
 )
@@ -9,8 +11,11 @@ import (
 var _ MessageDelegate = new(mockMessageDelegate)
 
 type mockMessageDelegate struct {
-	ackCalledCount int
-	delCalledCount int
+	ackCalledCount   int
+	delCalledCount   int
+	nackCalledCount  int
+	retryCalledCount int
+	nackDelay        time.Duration
 }
 
 // OnAck implements MessageDelegate.
@@ -23,6 +28,17 @@ func (d *mockMessageDelegate) OnDel(msg *Message) {
 	d.delCalledCount++
 }
 
+// OnNack implements MessageDelegate.
+func (d *mockMessageDelegate) OnNack(msg *Message, delay time.Duration) {
+	d.nackCalledCount++
+	d.nackDelay = delay
+}
+
+// OnRetry implements MessageDelegate.
+func (d *mockMessageDelegate) OnRetry(msg *Message) {
+	d.retryCalledCount++
+}
+
 func TestMessage(t *testing.T) {
 	d := new(mockMessageDelegate)
 
@@ -124,3 +140,59 @@ func TestMessage_Clone(t *testing.T) {
 		t.Errorf("cloned.XMessage expect:: %v, got:: %v\n", expectedXMessage, cloned.XMessage)
 	}
 }
+
+func TestMessage_Context(t *testing.T) {
+	{
+		m := &Message{}
+		if m.Context() == nil {
+			t.Errorf("Message.Context() should not be nil\n")
+		}
+	}
+	{
+		ctx, cancel := context.WithCancel(context.Background())
+		m := &Message{
+			ctx: ctx,
+		}
+		if ctx != m.Context() {
+			t.Errorf("Message.Context() expect:: %v, got:: %v\n", ctx, m.Context())
+		}
+
+		cancel()
+		if m.Context().Err() == nil {
+			t.Errorf("Message.Context().Err() should not be nil\n")
+		}
+	}
+}
+
+func TestMessage_Nack(t *testing.T) {
+	d := new(mockMessageDelegate)
+
+	m := &Message{
+		ConsumerGroup: "go-test-channel",
+		Stream:        "goTestStream",
+		Delegate:      d,
+		XMessage: &redis.XMessage{
+			ID: "1000",
+		},
+	}
+
+	m.Nack(3 * time.Second)
+	{
+		var expectedNackCalledCount int = 1
+		if expectedNackCalledCount != d.nackCalledCount {
+			t.Errorf("mockMessageDelegate.nackCalledCount expect:: %v, got:: %v\n", expectedNackCalledCount, d.nackCalledCount)
+		}
+		var expectedNackDelay time.Duration = 3 * time.Second
+		if expectedNackDelay != d.nackDelay {
+			t.Errorf("mockMessageDelegate.nackDelay expect:: %v, got:: %v\n", expectedNackDelay, d.nackDelay)
+		}
+	}
+
+	m.Retry()
+	{
+		var expectedRetryCalledCount int = 1
+		if expectedRetryCalledCount != d.retryCalledCount {
+			t.Errorf("mockMessageDelegate.retryCalledCount expect:: %v, got:: %v\n", expectedRetryCalledCount, d.retryCalledCount)
+		}
+	}
+}
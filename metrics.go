@@ -0,0 +1,257 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	semconv "go.opentelemetry.io/otel/semconv/v1.20.0"
+)
+
+const (
+	ErrorClassPanic     = "panic"
+	ErrorClassCanceled  = "canceled"
+	ErrorClassNoGroup   = "nogroup"
+	ErrorClassTransient = "transient"
+	ErrorClassRedis     = "redis"
+	ErrorClassOther     = "other"
+)
+
+var errorClassKey = attribute.Key("error.class")
+
+// classifyError returns the class of the error for the metrics.
+func classifyError(err error) string {
+	var consumerErr *ConsumerError
+	if errors.As(err, &consumerErr) && consumerErr.IsPanic() {
+		return ErrorClassPanic
+	}
+
+	switch {
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return ErrorClassCanceled
+	case hasRedisErrorPrefix(err, "NOGROUP"):
+		return ErrorClassNoGroup
+	case isTransientError(err):
+		return ErrorClassTransient
+	}
+
+	var redisErr RedisError
+	if errors.As(err, &redisErr) {
+		return ErrorClassRedis
+	}
+	return ErrorClassOther
+}
+
+func getMeter(provider metric.MeterProvider) metric.Meter {
+	if provider == nil {
+		provider = otel.GetMeterProvider()
+	}
+	return provider.Meter(METER_NAME)
+}
+
+type producerMetrics struct {
+	produced metric.Int64Counter
+	errors   metric.Int64Counter
+}
+
+func newProducerMetrics(provider metric.MeterProvider) (*producerMetrics, error) {
+	var (
+		meter = getMeter(provider)
+		m     = new(producerMetrics)
+		err   error
+	)
+
+	m.produced, err = meter.Int64Counter("redis.stream.produced",
+		metric.WithDescription("The number of messages written to the stream."),
+		metric.WithUnit("{message}"))
+	if err != nil {
+		return nil, err
+	}
+	m.errors, err = meter.Int64Counter("redis.stream.producer.errors",
+		metric.WithDescription("The number of errors occurred while writing messages."),
+		metric.WithUnit("{error}"))
+	if err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (m *producerMetrics) recordWrite(stream string, n int64, err error) {
+	if m == nil {
+		return
+	}
+
+	ctx := context.Background()
+	if err != nil {
+		m.errors.Add(ctx, 1, metric.WithAttributes(
+			semconv.MessagingDestinationName(stream),
+			errorClassKey.String(classifyError(err))))
+		return
+	}
+	m.produced.Add(ctx, n, metric.WithAttributes(
+		semconv.MessagingDestinationName(stream)))
+}
+
+type consumerMetrics struct {
+	group string
+
+	consumed        metric.Int64Counter
+	acked           metric.Int64Counter
+	deleted         metric.Int64Counter
+	claimed         metric.Int64Counter
+	ghostIDsPurged  metric.Int64Counter
+	errors          metric.Int64Counter
+	inFlight        metric.Int64UpDownCounter
+	handlerDuration metric.Float64Histogram
+	readBatchSize   metric.Int64Histogram
+	pollingDuration metric.Float64Histogram
+}
+
+func newConsumerMetrics(provider metric.MeterProvider, group string) (*consumerMetrics, error) {
+	var (
+		meter = getMeter(provider)
+		m     = &consumerMetrics{group: group}
+		err   error
+	)
+
+	for _, c := range []struct {
+		counter     *metric.Int64Counter
+		name        string
+		description string
+	}{
+		{&m.consumed, "redis.stream.consumed", "The number of messages delivered to the handler."},
+		{&m.acked, "redis.stream.acked", "The number of messages acknowledged by XACK."},
+		{&m.deleted, "redis.stream.deleted", "The number of messages deleted by XDEL."},
+		{&m.claimed, "redis.stream.claimed", "The number of pending messages claimed from other consumers."},
+		{&m.ghostIDsPurged, "redis.stream.ghost_ids.purged", "The number of pending message IDs purged since the messages have been deleted."},
+	} {
+		*c.counter, err = meter.Int64Counter(c.name,
+			metric.WithDescription(c.description),
+			metric.WithUnit("{message}"))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	m.errors, err = meter.Int64Counter("redis.stream.consumer.errors",
+		metric.WithDescription("The number of errors occurred while consuming messages."),
+		metric.WithUnit("{error}"))
+	if err != nil {
+		return nil, err
+	}
+	m.inFlight, err = meter.Int64UpDownCounter("redis.stream.inflight",
+		metric.WithDescription("The number of messages being handled."),
+		metric.WithUnit("{message}"))
+	if err != nil {
+		return nil, err
+	}
+	m.handlerDuration, err = meter.Float64Histogram("redis.stream.handler.duration",
+		metric.WithDescription("The duration of the handler processing messages."),
+		metric.WithUnit("s"))
+	if err != nil {
+		return nil, err
+	}
+	m.readBatchSize, err = meter.Int64Histogram("redis.stream.read.batch_size",
+		metric.WithDescription("The number of messages replied by XREADGROUP."),
+		metric.WithUnit("{message}"))
+	if err != nil {
+		return nil, err
+	}
+	m.pollingDuration, err = meter.Float64Histogram("redis.stream.polling.duration",
+		metric.WithDescription("The latency of XREADGROUP."),
+		metric.WithUnit("s"))
+	if err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (m *consumerMetrics) streamAttributes(stream string) metric.MeasurementOption {
+	return metric.WithAttributes(
+		semconv.MessagingDestinationName(stream),
+		messagingConsumerGroupKey.String(m.group))
+}
+
+func (m *consumerMetrics) addConsumed(stream string, n int64) {
+	if m == nil || n == 0 {
+		return
+	}
+	m.consumed.Add(context.Background(), n, m.streamAttributes(stream))
+}
+
+func (m *consumerMetrics) addAcked(stream string, n int64) {
+	if m == nil || n == 0 {
+		return
+	}
+	m.acked.Add(context.Background(), n, m.streamAttributes(stream))
+}
+
+func (m *consumerMetrics) addDeleted(stream string, n int64) {
+	if m == nil || n == 0 {
+		return
+	}
+	m.deleted.Add(context.Background(), n, m.streamAttributes(stream))
+}
+
+func (m *consumerMetrics) addClaimed(stream string, n int64) {
+	if m == nil || n == 0 {
+		return
+	}
+	m.claimed.Add(context.Background(), n, m.streamAttributes(stream))
+}
+
+func (m *consumerMetrics) addGhostIDsPurged(stream string, n int64) {
+	if m == nil || n == 0 {
+		return
+	}
+	m.ghostIDsPurged.Add(context.Background(), n, m.streamAttributes(stream))
+}
+
+func (m *consumerMetrics) addError(err error) {
+	if m == nil || err == nil {
+		return
+	}
+	m.errors.Add(context.Background(), 1, metric.WithAttributes(
+		messagingConsumerGroupKey.String(m.group),
+		errorClassKey.String(classifyError(err))))
+}
+
+// startHandling records the messages which are being handled, and returns
+// a func to record the end of handling.
+func (m *consumerMetrics) startHandling(stream string, n int64) func() {
+	if m == nil {
+		return func() {}
+	}
+
+	var (
+		ctx   = context.Background()
+		start = time.Now()
+		attrs = m.streamAttributes(stream)
+	)
+	m.inFlight.Add(ctx, n, attrs)
+	return func() {
+		m.inFlight.Add(ctx, -n, attrs)
+		m.handlerDuration.Record(ctx, time.Since(start).Seconds(), attrs)
+	}
+}
+
+func (m *consumerMetrics) recordRead(start time.Time, streams []XStream) {
+	if m == nil {
+		return
+	}
+
+	var (
+		ctx  = context.Background()
+		size int64
+	)
+	for _, stream := range streams {
+		size += int64(len(stream.Messages))
+	}
+	m.pollingDuration.Record(ctx, time.Since(start).Seconds(),
+		metric.WithAttributes(messagingConsumerGroupKey.String(m.group)))
+	m.readBatchSize.Record(ctx, size,
+		metric.WithAttributes(messagingConsumerGroupKey.String(m.group)))
+}
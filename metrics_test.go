@@ -0,0 +1,196 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	redis "github.com/go-redis/redis/v7"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+func collectMetrics(t *testing.T, reader sdkmetric.Reader) map[string]metricdata.Aggregation {
+	var rm metricdata.ResourceMetrics
+	if err := reader.Collect(context.Background(), &rm); err != nil {
+		t.Fatal(err)
+	}
+
+	var metrics = make(map[string]metricdata.Aggregation)
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			metrics[m.Name] = m.Data
+		}
+	}
+	return metrics
+}
+
+func sumInt64(data metricdata.Aggregation) int64 {
+	var total int64
+	switch v := data.(type) {
+	case metricdata.Sum[int64]:
+		for _, dp := range v.DataPoints {
+			total += dp.Value
+		}
+	case metricdata.Histogram[int64]:
+		for _, dp := range v.DataPoints {
+			total += dp.Sum
+		}
+	}
+	return total
+}
+
+func countHistogram(data metricdata.Aggregation) uint64 {
+	var total uint64
+	if v, ok := data.(metricdata.Histogram[float64]); ok {
+		for _, dp := range v.DataPoints {
+			total += dp.Count
+		}
+	}
+	return total
+}
+
+func TestClassifyError(t *testing.T) {
+	expectedAndGot := [][]string{
+		// run classifyError()                                           , expected
+		{classifyError(newPanicConsumerError("boom", nil, nil)), ErrorClassPanic},
+		{classifyError(context.Canceled), ErrorClassCanceled},
+		{classifyError(mockRedisError("NOGROUP No such key")), ErrorClassNoGroup},
+		{classifyError(mockRedisError("LOADING Redis is loading")), ErrorClassTransient},
+		{classifyError(mockRedisError("WRONGTYPE Operation")), ErrorClassRedis},
+		{classifyError(fmt.Errorf("unknown")), ErrorClassOther},
+	}
+
+	for i, v := range expectedAndGot {
+		expected, got := v[1], v[0]
+		if expected != got {
+			t.Errorf("assert classifyError() at %d :: expected %+v, got %+v", i, expected, got)
+		}
+	}
+}
+
+func TestConsumer_Subscribe_WithMeterProvider(t *testing.T) {
+	{
+		/*
+			XGROUP CREATE gotestStream1 gotestGroup $ MKSTREAM
+
+			XGROUP DESTROY gotestStream1 gotestGroup
+
+			DEL gotestStream1
+		*/
+		client := redis.NewClient(&redis.Options{
+			Addr: __TEST_REDIS_SERVER,
+			DB:   0,
+		})
+		if client == nil {
+			panic("fail to create redis.Client")
+		}
+		defer client.Close()
+
+		for _, cmd := range []string{
+			"XGROUP CREATE gotestStream1 gotestGroup $ MKSTREAM",
+		} {
+			_, err := execRedisCommand(client, cmd).Result()
+			if err != nil {
+				panic(err)
+			}
+		}
+		defer func() {
+			for _, cmd := range []string{
+				"XGROUP DESTROY gotestStream1 gotestGroup",
+
+				"DEL gotestStream1",
+			} {
+				_, err := execRedisCommand(client, cmd).Result()
+				if err != nil {
+					panic(err)
+				}
+			}
+		}()
+	}
+
+	var (
+		reader   = sdkmetric.NewManualReader()
+		provider = sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+	)
+	defer provider.Shutdown(context.Background())
+
+	opt := redis.UniversalOptions{
+		Addrs: []string{__TEST_REDIS_SERVER},
+		DB:    0,
+	}
+
+	// produce
+	{
+		p, err := NewProducer(&ProducerConfig{
+			UniversalOptions: &opt,
+			MeterProvider:    provider,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer p.Close()
+
+		for _, name := range []string{"luffy", "nami", "zoro"} {
+			_, err = p.Write("gotestStream1", map[string]interface{}{"name": name})
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	// consume
+	{
+		c := &Consumer{
+			Group:               "gotestGroup",
+			Name:                "gotestConsumer",
+			RedisOption:         &opt,
+			MaxInFlight:         8,
+			MaxPollingTimeout:   10 * time.Millisecond,
+			ClaimMinIdleTime:    5 * time.Second,
+			IdlingTimeout:       10 * time.Millisecond,
+			ClaimSensitivity:    8,
+			ClaimOccurrenceRate: 1,
+			MeterProvider:       provider,
+			MessageHandler: func(message *Message) {
+				message.Ack()
+				message.Del()
+			},
+		}
+
+		err := c.Subscribe(
+			Stream("gotestStream1").NeverDeliveredOffset(),
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		time.Sleep(500 * time.Millisecond)
+		c.Close()
+	}
+
+	// assert
+	metrics := collectMetrics(t, reader)
+
+	for name, expected := range map[string]int64{
+		"redis.stream.produced":        3,
+		"redis.stream.consumed":        3,
+		"redis.stream.acked":           3,
+		"redis.stream.deleted":         3,
+		"redis.stream.inflight":        0,
+		"redis.stream.read.batch_size": 3,
+	} {
+		if got := sumInt64(metrics[name]); expected != got {
+			t.Errorf("%s expect:: %v, got:: %v\n", name, expected, got)
+		}
+	}
+
+	var expectedHandlerCount uint64 = 3
+	if got := countHistogram(metrics["redis.stream.handler.duration"]); expectedHandlerCount != got {
+		t.Errorf("redis.stream.handler.duration count expect:: %v, got:: %v\n", expectedHandlerCount, got)
+	}
+	if got := countHistogram(metrics["redis.stream.polling.duration"]); got == 0 {
+		t.Errorf("redis.stream.polling.duration should be recorded")
+	}
+}
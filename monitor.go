@@ -0,0 +1,130 @@
+package redis
+
+import (
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// Monitor samples the lag of the consumer groups periodically, and
+// publishes them to the LagHandler.
+type Monitor struct {
+	Client       *AdminClient
+	Groups       []string      // 僅回報指定的 consumer group, 未設定時回報全部
+	Interval     time.Duration // 取樣的間隔, 預設為 DEFAULT_MONITOR_INTERVAL
+	LagHandler   GroupLagHandleProc
+	ErrorHandler ErrorHandleProc
+	Logger       *log.Logger
+
+	streams  []string
+	stopChan chan struct{}
+	done     chan struct{}
+
+	mutex    sync.Mutex
+	running  bool
+	disposed bool
+}
+
+func (m *Monitor) Start(streams ...string) error {
+	if len(streams) == 0 {
+		return fmt.Errorf("specified streams is empty")
+	}
+	if m.Client == nil {
+		return fmt.Errorf("the Monitor.Client is not specified")
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if m.disposed {
+		return fmt.Errorf("the Monitor has been disposed")
+	}
+	if m.running {
+		return fmt.Errorf("the Monitor is running")
+	}
+	m.running = true
+
+	if m.Interval <= 0 {
+		m.Interval = DEFAULT_MONITOR_INTERVAL
+	}
+	if m.Logger == nil {
+		m.Logger = defaultLogger
+	}
+
+	m.streams = streams
+	m.stopChan = make(chan struct{})
+	m.done = make(chan struct{})
+
+	go func() {
+		defer close(m.done)
+
+		ticker := time.NewTicker(m.Interval)
+		defer ticker.Stop()
+
+		for {
+			m.sample()
+
+			select {
+			case <-m.stopChan:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	return nil
+}
+
+func (m *Monitor) Close() {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if m.disposed {
+		return
+	}
+	m.disposed = true
+
+	if m.running {
+		close(m.stopChan)
+		<-m.done
+		m.running = false
+	}
+}
+
+// Sample reports the lag of the consumer groups of the streams once.
+func (m *Monitor) Sample(streams ...string) ([]GroupLag, error) {
+	var lags []GroupLag
+	for _, stream := range streams {
+		streamLags, err := m.Client.Lag(stream, m.Groups...)
+		if err != nil {
+			return lags, err
+		}
+		lags = append(lags, streamLags...)
+	}
+	return lags, nil
+}
+
+func (m *Monitor) sample() {
+	var lags []GroupLag
+	for _, stream := range m.streams {
+		streamLags, err := m.Client.Lag(stream, m.Groups...)
+		if err != nil {
+			m.processError(err)
+			continue
+		}
+		lags = append(lags, streamLags...)
+	}
+
+	if m.LagHandler != nil {
+		m.LagHandler(lags)
+	}
+}
+
+func (m *Monitor) processError(err error) {
+	if m.ErrorHandler != nil {
+		if m.ErrorHandler(err) {
+			return
+		}
+	}
+	m.Logger.Printf("%% Error: %v\n", err)
+}
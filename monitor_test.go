@@ -0,0 +1,196 @@
+package redis
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	redis "github.com/go-redis/redis/v7"
+)
+
+func setupMonitorTestStream() (ids []string, teardown func()) {
+	/*
+		XGROUP CREATE gotestStream1 gotestGroup 0 MKSTREAM
+
+		XADD gotestStream1 * name luffy age 19
+		XADD gotestStream1 * name nami age 21
+		XADD gotestStream1 * name zoro age 21
+		XADD gotestStream1 * name sanji age 21
+
+		XREADGROUP GROUP gotestGroup gotestConsumer COUNT 2 STREAMS gotestStream1 >
+
+		XGROUP DESTROY gotestStream1 gotestGroup
+
+		DEL gotestStream1
+	*/
+	client := redis.NewClient(&redis.Options{
+		Addr: __TEST_REDIS_SERVER,
+		DB:   0,
+	})
+	if client == nil {
+		panic("fail to create redis.Client")
+	}
+
+	_, err := execRedisCommand(client, "XGROUP CREATE gotestStream1 gotestGroup 0 MKSTREAM").Result()
+	if err != nil {
+		panic(err)
+	}
+	for _, cmd := range []string{
+		"XADD gotestStream1 * name luffy age 19",
+		"XADD gotestStream1 * name nami age 21",
+		"XADD gotestStream1 * name zoro age 21",
+		"XADD gotestStream1 * name sanji age 21",
+	} {
+		id, err := execRedisCommand(client, cmd).Text()
+		if err != nil {
+			panic(err)
+		}
+		ids = append(ids, id)
+	}
+	_, err = execRedisCommand(client, "XREADGROUP GROUP gotestGroup gotestConsumer COUNT 2 STREAMS gotestStream1 >").Result()
+	if err != nil {
+		panic(err)
+	}
+
+	return ids, func() {
+		defer client.Close()
+
+		for _, cmd := range []string{
+			"XGROUP DESTROY gotestStream1 gotestGroup",
+
+			"DEL gotestStream1",
+		} {
+			_, err := execRedisCommand(client, cmd).Result()
+			if err != nil {
+				panic(err)
+			}
+		}
+	}
+}
+
+func TestAdminClient_Lag(t *testing.T) {
+	ids, teardown := setupMonitorTestStream()
+	defer teardown()
+
+	admin, err := NewAdminClient(&UniversalOptions{
+		Addrs: []string{__TEST_REDIS_SERVER},
+		DB:    0,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer admin.Close()
+
+	lags, err := admin.Lag("gotestStream1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(lags) != 1 {
+		t.Fatalf("expect %d lags, but got %d lags", 1, len(lags))
+	}
+
+	lag := lags[0]
+	{
+		var expectedGroup string = "gotestGroup"
+		if expectedGroup != lag.Group {
+			t.Errorf("GroupLag.Group expect:: %v, got:: %v\n", expectedGroup, lag.Group)
+		}
+		var expectedLength int64 = 4
+		if expectedLength != lag.Length {
+			t.Errorf("GroupLag.Length expect:: %v, got:: %v\n", expectedLength, lag.Length)
+		}
+		var expectedLastGeneratedID string = ids[3]
+		if expectedLastGeneratedID != lag.LastGeneratedID {
+			t.Errorf("GroupLag.LastGeneratedID expect:: %v, got:: %v\n", expectedLastGeneratedID, lag.LastGeneratedID)
+		}
+		var expectedLastDeliveredID string = ids[1]
+		if expectedLastDeliveredID != lag.LastDeliveredID {
+			t.Errorf("GroupLag.LastDeliveredID expect:: %v, got:: %v\n", expectedLastDeliveredID, lag.LastDeliveredID)
+		}
+		var expectedPending int64 = 2
+		if expectedPending != lag.Pending {
+			t.Errorf("GroupLag.Pending expect:: %v, got:: %v\n", expectedPending, lag.Pending)
+		}
+		var expectedConsumerPending int64 = 2
+		if expectedConsumerPending != lag.ConsumerPending["gotestConsumer"] {
+			t.Errorf("GroupLag.ConsumerPending expect:: %v, got:: %v\n", expectedConsumerPending, lag.ConsumerPending)
+		}
+	}
+
+	// the lag computed without Redis 7
+	{
+		count, truncated, err := admin.countEntriesAfter("gotestStream1", lag.LastDeliveredID, lag.LastGeneratedID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var expectedCount int64 = 2
+		if expectedCount != count {
+			t.Errorf("AdminClient.countEntriesAfter() expect:: %v, got:: %v\n", expectedCount, count)
+		}
+		if truncated {
+			t.Errorf("AdminClient.countEntriesAfter() should not be truncated")
+		}
+	}
+
+	lags, err = admin.Lag("gotestStream1", "unknownGroup")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(lags) != 0 {
+		t.Errorf("expect %d lags, but got %d lags", 0, len(lags))
+	}
+}
+
+func TestMonitor(t *testing.T) {
+	_, teardown := setupMonitorTestStream()
+	defer teardown()
+
+	admin, err := NewAdminClient(&UniversalOptions{
+		Addrs: []string{__TEST_REDIS_SERVER},
+		DB:    0,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer admin.Close()
+
+	var (
+		sampled int32
+		pending int64
+	)
+
+	m := &Monitor{
+		Client:   admin,
+		Interval: 50 * time.Millisecond,
+		LagHandler: func(lags []GroupLag) {
+			atomic.AddInt32(&sampled, 1)
+			for _, lag := range lags {
+				atomic.StoreInt64(&pending, lag.Pending)
+			}
+		},
+		ErrorHandler: func(err error) (disposed bool) {
+			t.Errorf("%+v\n", err)
+			return true
+		},
+	}
+
+	err = m.Start("gotestStream1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(200 * time.Millisecond)
+	m.Close()
+
+	if atomic.LoadInt32(&sampled) < 2 {
+		t.Errorf("Monitor should sample at least %d times, got:: %d", 2, sampled)
+	}
+	var expectedPending int64 = 2
+	if expectedPending != atomic.LoadInt64(&pending) {
+		t.Errorf("GroupLag.Pending expect:: %v, got:: %v\n", expectedPending, pending)
+	}
+
+	err = m.Start("gotestStream1")
+	if err == nil {
+		t.Errorf("Monitor.Start() should return error after closed")
+	}
+}
@@ -0,0 +1,32 @@
+package redis
+
+var _ ProduceMessageOption = new(ProduceBatchOption)
+
+// ProduceBatchOption configures Producer.WriteBatch() and
+// Producer.WriteBatchMessages(), it is ignored by the other writes.
+type ProduceBatchOption func(setting *ProduceBatchSetting)
+
+func (proc ProduceBatchOption) applyContent(msg *MessageContent) error {
+	return nil
+}
+
+func (proc ProduceBatchOption) applyID(id string) string {
+	return id
+}
+
+func (proc ProduceBatchOption) applyXAdd(setting *XAddSetting) {}
+
+func (proc ProduceBatchOption) applyBatch(setting *ProduceBatchSetting) {
+	proc(setting)
+}
+
+// WithAtomicBatch sends the batch in MULTI/EXEC instead of a pipeline, the
+// batch is aborted without sending if any message cannot be prepared.
+// NOTE: Redis doesn't roll back the other messages if one of the XADD
+// fails in the transaction, and all the streams must be in the same hash
+// slot in cluster mode.
+func WithAtomicBatch() ProduceBatchOption {
+	return func(setting *ProduceBatchSetting) {
+		setting.Atomic = true
+	}
+}
@@ -20,6 +20,8 @@ func (proc ProduceMessageContentOption) applyID(id string) string {
 	return id
 }
 
+func (proc ProduceMessageContentOption) applyXAdd(setting *XAddSetting) {}
+
 func WithTracePropagation(ctx context.Context, propagator propagation.TextMapPropagator) ProduceMessageContentOption {
 	return func(msg *MessageContent) error {
 		carrier := tracing.NewMessageStateCarrier(&msg.State)
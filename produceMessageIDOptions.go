@@ -11,6 +11,8 @@ func (proc ProduceMessageIDOption) applyID(id string) string {
 	return proc(id)
 }
 
+func (proc ProduceMessageIDOption) applyXAdd(setting *XAddSetting) {}
+
 func WithMessageID(id string) ProduceMessageIDOption {
 	return func(string) string {
 		return id
@@ -0,0 +1,87 @@
+package redis
+
+import "time"
+
+var _ ProduceMessageOption = new(ProduceMessageXAddOption)
+
+type ProduceMessageXAddOption func(setting *XAddSetting)
+
+func (proc ProduceMessageXAddOption) applyContent(msg *MessageContent) error {
+	return nil
+}
+
+func (proc ProduceMessageXAddOption) applyID(id string) string {
+	return id
+}
+
+func (proc ProduceMessageXAddOption) applyXAdd(setting *XAddSetting) {
+	proc(setting)
+}
+
+// WithMaxLen trims the stream to the maxLen entries, it overrides the
+// retention of the ProducerConfig.
+func WithMaxLen(maxLen int64) ProduceMessageXAddOption {
+	return func(setting *XAddSetting) {
+		setting.Retention = StreamRetention{
+			MaxLen:      maxLen,
+			Approximate: setting.Retention.Approximate,
+		}
+	}
+}
+
+// WithMinID evicts the entries whose ID is lower than the minID, it
+// overrides the retention of the ProducerConfig.
+func WithMinID(minID string) ProduceMessageXAddOption {
+	return func(setting *XAddSetting) {
+		setting.Retention = StreamRetention{
+			MinID:       minID,
+			Approximate: setting.Retention.Approximate,
+		}
+	}
+}
+
+// WithMaxAge evicts the entries added before the age, e.g. keeping the
+// entries of the last 24 hours. It overrides the retention of the
+// ProducerConfig.
+func WithMaxAge(age time.Duration) ProduceMessageXAddOption {
+	return func(setting *XAddSetting) {
+		setting.Retention = StreamRetention{
+			MaxAge:      age,
+			Approximate: setting.Retention.Approximate,
+		}
+	}
+}
+
+// WithApproximateTrim trims the stream with ~, it should be placed after
+// WithMaxLen, WithMinID or WithMaxAge.
+func WithApproximateTrim() ProduceMessageXAddOption {
+	return func(setting *XAddSetting) {
+		setting.Retention.Approximate = true
+	}
+}
+
+// WithoutTrim disables the retention of the ProducerConfig.
+func WithoutTrim() ProduceMessageXAddOption {
+	return func(setting *XAddSetting) {
+		setting.Retention = StreamRetention{}
+	}
+}
+
+// WithNoMkStream doesn't create the stream if it doesn't exist, the
+// Producer returns Nil in that case. It is available since Redis 6.2.
+func WithNoMkStream() ProduceMessageXAddOption {
+	return func(setting *XAddSetting) {
+		setting.NoMkStream = true
+	}
+}
+
+// WithIdempotencyKey writes the message only once within the
+// IdempotencyTTL of the ProducerConfig, the ID of the original message is
+// returned on duplicates. The key is also written to the MessageState for
+// DeduplicationMiddleware. It must be set per message by BatchMessage.Options
+// in Producer.WriteBatchMessages().
+func WithIdempotencyKey(key string) ProduceMessageXAddOption {
+	return func(setting *XAddSetting) {
+		setting.IdempotencyKey = key
+	}
+}
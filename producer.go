@@ -1,17 +1,34 @@
 package redis
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log"
 	"sync"
+	"time"
 
+	"github.com/Bofry/lib-redis-stream/tracing"
+	"github.com/Bofry/trace"
 	redis "github.com/go-redis/redis/v7"
+	"go.opentelemetry.io/otel/propagation"
+	oteltrace "go.opentelemetry.io/otel/trace"
+	"golang.org/x/exp/slices"
 )
 
 type Producer struct {
 	handle redis.UniversalClient
 
-	logger *log.Logger
+	logger     *log.Logger
+	tracer     oteltrace.Tracer
+	propagator propagation.TextMapPropagator
+	metrics    *producerMetrics
+
+	retention      StreamRetention
+	idempotencyTTL time.Duration
+	retryPolicy    *RetryPolicy
+	breaker        *CircuitBreaker
+	codec          Codec
 
 	wg          sync.WaitGroup
 	mutex       sync.Mutex
@@ -34,6 +51,12 @@ func (p *Producer) Handle() redis.UniversalClient {
 	return p.handle
 }
 
+// CircuitState returns the state of the CircuitBreaker, it is always
+// CircuitClosed if no CircuitBreaker configured.
+func (p *Producer) CircuitState() CircuitState {
+	return p.breaker.State()
+}
+
 func (p *Producer) WriteContent(stream string, msg *MessageContent, opts ...ProduceMessageOption) (string, error) {
 	if p.disposed {
 		return "", fmt.Errorf("the Producer has been disposed")
@@ -42,27 +65,43 @@ func (p *Producer) WriteContent(stream string, msg *MessageContent, opts ...Prod
 		p.logger.Panic("the Producer haven't be initialized yet")
 	}
 
-	id := StreamAsteriskID
+	id, setting, err := p.applyOptions(msg, opts)
+	if err != nil {
+		return "", err
+	}
+	if err := msg.validate(); err != nil {
+		return "", err
+	}
 
-	// apply options
-	for _, opt := range opts {
-		switch opt.(type) {
-		case ProduceMessageContentOption:
-			err := opt.applyContent(msg)
-			if err != nil {
-				return "", err
-			}
-		case ProduceMessageIDOption:
-			id = opt.applyID(id)
-		}
+	// the producer span is the child of the span injected by
+	// WithTracePropagation
+	ctx := context.Background()
+	if p.tracer != nil {
+		ctx = p.propagator.Extract(ctx, tracing.NewMessageStateCarrier(&msg.State))
 	}
 
-	var values map[string]interface{}
+	var values = make(map[string]interface{}, len(msg.Values)+msg.State.Len())
 	msg.WriteTo(values)
-	return p.internalWrite(stream, id, values)
+	return p.tracedWrite(ctx, stream, id, values, msg.State.contentKeyPrefix, &setting)
+}
+
+// WriteObject encodes the v by the Codec of the ProducerConfig and writes
+// it as the payload, the consumers decode it by Message.Decode().
+func (p *Producer) WriteObject(stream string, v interface{}, opts ...ProduceMessageOption) (string, error) {
+	msg, err := EncodeMessageContent(p.codec, v)
+	if err != nil {
+		return "", err
+	}
+	return p.WriteContent(stream, msg, opts...)
 }
 
 func (p *Producer) Write(stream string, values map[string]interface{}, opts ...ProduceMessageOption) (string, error) {
+	return p.WriteContext(context.Background(), stream, values, opts...)
+}
+
+// WriteContext writes the message within the deadline of the context,
+// the retries are stopped if the context is done.
+func (p *Producer) WriteContext(ctx context.Context, stream string, values map[string]interface{}, opts ...ProduceMessageOption) (string, error) {
 	if p.disposed {
 		return "", fmt.Errorf("the Producer has been disposed")
 	}
@@ -71,16 +110,139 @@ func (p *Producer) Write(stream string, values map[string]interface{}, opts ...P
 	}
 
 	id := StreamAsteriskID
+	setting := p.newXAddSetting()
 
 	// apply options
 	for _, opt := range opts {
 		switch opt.(type) {
 		case ProduceMessageIDOption:
 			id = opt.applyID(id)
+		case ProduceMessageXAddOption:
+			opt.applyXAdd(&setting)
+		}
+	}
+
+	return p.tracedWrite(ctx, stream, id, values, _DefaultMessageStateKeyPrefix, &setting)
+}
+
+// WriteBatch writes the messages to the stream in a pipeline, the IDs and
+// the errors of the messages are returned in order. The options are applied
+// to each message. The first error of the messages is returned if any.
+func (p *Producer) WriteBatch(stream string, messages []map[string]interface{}, opts ...ProduceMessageOption) ([]WriteResult, error) {
+	return p.WriteBatchContext(context.Background(), stream, messages, opts...)
+}
+
+// WriteBatchContext writes the messages like WriteBatch within the deadline
+// of the context, the retries are stopped if the context is done.
+func (p *Producer) WriteBatchContext(ctx context.Context, stream string, messages []map[string]interface{}, opts ...ProduceMessageOption) ([]WriteResult, error) {
+	var batch = make([]BatchMessage, len(messages))
+	for i, values := range messages {
+		batch[i] = BatchMessage{
+			Stream: stream,
+			Values: values,
+		}
+	}
+	return p.WriteBatchMessagesContext(ctx, batch, opts...)
+}
+
+// WriteBatchMessages writes the messages to their streams in a pipeline.
+// The options are applied to each message before its own options. The
+// failed messages are retried by the RetryPolicy through the CircuitBreaker.
+// See also WriteBatch().
+func (p *Producer) WriteBatchMessages(messages []BatchMessage, opts ...ProduceMessageOption) ([]WriteResult, error) {
+	return p.WriteBatchMessagesContext(context.Background(), messages, opts...)
+}
+
+// WriteBatchMessagesContext writes the messages like WriteBatchMessages
+// within the deadline of the context, the retries are stopped if the
+// context is done.
+func (p *Producer) WriteBatchMessagesContext(ctx context.Context, messages []BatchMessage, opts ...ProduceMessageOption) ([]WriteResult, error) {
+	if p.disposed {
+		return nil, fmt.Errorf("the Producer has been disposed")
+	}
+	if !p.initialized {
+		p.logger.Panic("the Producer haven't be initialized yet")
+	}
+
+	var (
+		setting ProduceBatchSetting
+		xadd    XAddSetting
+	)
+	for _, opt := range opts {
+		switch opt := opt.(type) {
+		case ProduceBatchOption:
+			opt.applyBatch(&setting)
+		case ProduceMessageXAddOption:
+			opt.applyXAdd(&xadd)
 		}
 	}
+	// NOTE: the same key makes the messages except the first one taken as
+	// duplicates, the key should be set by BatchMessage.Options.
+	if len(xadd.IdempotencyKey) > 0 {
+		return nil, ErrBatchIdempotencyKey
+	}
+
+	p.wg.Add(1)
+	defer p.wg.Done()
 
-	return p.internalWrite(stream, id, values)
+	var (
+		results  = make([]WriteResult, len(messages))
+		requests = make([]*xaddRequest, len(messages))
+	)
+	for i := range messages {
+		req, err := p.prepareBatchMessage(&messages[i], opts)
+		if err != nil {
+			if setting.Atomic {
+				for _, req := range requests[:i] {
+					req.end("", err)
+				}
+				return nil, err
+			}
+			results[i].Err = err
+			continue
+		}
+		requests[i] = req
+	}
+	if !slices.ContainsFunc(requests, func(req *xaddRequest) bool { return req != nil }) {
+		// nothing to send
+		for _, r := range results {
+			if r.Err != nil {
+				return results, r.Err
+			}
+		}
+		return results, nil
+	}
+
+	p.internalWriteBatch(ctx, requests, setting.Atomic)
+
+	var firstErr error
+	for i, req := range requests {
+		if req == nil {
+			if firstErr == nil {
+				firstErr = results[i].Err
+			}
+			continue
+		}
+
+		reply, err := req.cmd.Result()
+		if err != nil {
+			if err != redis.Nil {
+				p.metrics.recordWrite(req.stream, 0, err)
+			}
+			if firstErr == nil {
+				firstErr = err
+			}
+		} else {
+			p.metrics.recordWrite(req.stream, 1, nil)
+		}
+		req.end(reply, err)
+
+		results[i] = WriteResult{
+			ID:  reply,
+			Err: err,
+		}
+	}
+	return results, firstErr
 }
 
 func (p *Producer) Close() {
@@ -102,6 +264,32 @@ func (p *Producer) init(config *ProducerConfig) error {
 		return nil
 	}
 
+	if config.Retention != nil {
+		if err := config.Retention.validate(); err != nil {
+			return err
+		}
+		p.retention = *config.Retention
+	}
+
+	p.idempotencyTTL = config.IdempotencyTTL
+	if p.idempotencyTTL <= 0 {
+		p.idempotencyTTL = DEFAULT_IDEMPOTENCY_TTL
+	}
+
+	p.codec = config.Codec
+	if p.codec == nil {
+		p.codec = JSONCodec{}
+	}
+
+	p.retryPolicy = config.RetryPolicy
+	p.breaker = config.CircuitBreaker
+
+	// config metrics
+	metrics, err := newProducerMetrics(config.MeterProvider)
+	if err != nil {
+		return err
+	}
+
 	client, err := createRedisUniversalClient(config.UniversalOptions)
 	if err != nil {
 		return err
@@ -110,6 +298,11 @@ func (p *Producer) init(config *ProducerConfig) error {
 	// config logger
 	p.configureLogger(config)
 
+	// config tracer
+	p.configureTracer(config)
+
+	p.metrics = metrics
+
 	p.handle = client
 
 	p.initialized = true
@@ -125,19 +318,242 @@ func (p *Producer) configureLogger(config *ProducerConfig) {
 	p.logger = defaultLogger
 }
 
-func (p *Producer) internalWrite(stream string, id string, values map[string]interface{}) (string, error) {
+func (p *Producer) configureTracer(config *ProducerConfig) {
+	p.tracer = config.Tracer
+	p.propagator = config.TextMapPropagator
+	if p.propagator == nil {
+		p.propagator = trace.GetTextMapPropagator()
+	}
+}
+
+func (p *Producer) newXAddSetting() XAddSetting {
+	return XAddSetting{
+		Retention:      p.retention,
+		IdempotencyTTL: p.idempotencyTTL,
+	}
+}
+
+func (p *Producer) applyOptions(msg *MessageContent, opts []ProduceMessageOption) (string, XAddSetting, error) {
+	id := StreamAsteriskID
+	setting := p.newXAddSetting()
+
+	for _, opt := range opts {
+		switch opt.(type) {
+		case ProduceMessageContentOption:
+			err := opt.applyContent(msg)
+			if err != nil {
+				return "", setting, err
+			}
+		case ProduceMessageIDOption:
+			id = opt.applyID(id)
+		case ProduceMessageXAddOption:
+			opt.applyXAdd(&setting)
+		}
+	}
+	return id, setting, nil
+}
+
+// prepareBatchMessage builds the XADD of the message, the producer span of
+// the message is started if the tracer is set.
+func (p *Producer) prepareBatchMessage(m *BatchMessage, opts []ProduceMessageOption) (*xaddRequest, error) {
+	msg := &MessageContent{
+		Values: m.Values,
+	}
+	id, setting, err := p.applyOptions(msg, append(opts[:len(opts):len(opts)], m.Options...))
+	if err != nil {
+		return nil, err
+	}
+	if err := msg.validate(); err != nil {
+		return nil, err
+	}
+
+	var values = make(map[string]interface{}, len(m.Values)+msg.State.Len()+3)
+	msg.WriteTo(values)
+	if len(setting.IdempotencyKey) > 0 {
+		values[msg.State.contentKeyPrefix+MESSAGE_STATE_IDEMPOTENCY_KEY] = setting.IdempotencyKey
+	}
+
+	req := &xaddRequest{
+		stream:  m.Stream,
+		id:      id,
+		values:  values,
+		setting: setting,
+	}
+	if p.tracer != nil {
+		ctx := p.propagator.Extract(context.Background(), tracing.NewMessageStateCarrier(&msg.State))
+		ctx, req.span = startProducerSpan(p.tracer, ctx, m.Stream)
+		p.propagator.Inject(ctx, &messageValuesCarrier{
+			values: values,
+			prefix: msg.State.contentKeyPrefix,
+		})
+	}
+
+	if err := req.reset(); err != nil {
+		req.end("", err)
+		return nil, err
+	}
+	return req, nil
+}
+
+// tracedWrite wraps internalWrite in a producer span if the tracer is set,
+// the span context is injected into the MessageState of the message.
+func (p *Producer) tracedWrite(ctx context.Context, stream string, id string, values map[string]interface{}, keyPrefix string, setting *XAddSetting) (string, error) {
+	if len(setting.IdempotencyKey) == 0 && p.tracer == nil {
+		return p.internalWrite(ctx, stream, id, values, setting)
+	}
+
+	// NOTE: copy the values to avoid modifying the caller's map
+	var container = make(map[string]interface{}, len(values)+3)
+	for k, v := range values {
+		container[k] = v
+	}
+	if len(setting.IdempotencyKey) > 0 {
+		container[keyPrefix+MESSAGE_STATE_IDEMPOTENCY_KEY] = setting.IdempotencyKey
+	}
+
+	if p.tracer == nil {
+		return p.internalWrite(ctx, stream, id, container, setting)
+	}
+
+	ctx, span := startProducerSpan(p.tracer, ctx, stream)
+	defer span.End()
+
+	p.propagator.Inject(ctx, &messageValuesCarrier{
+		values: container,
+		prefix: keyPrefix,
+	})
+
+	reply, err := p.internalWrite(ctx, stream, id, container, setting)
+	endProducerSpan(span, reply, container, err)
+	return reply, err
+}
+
+func (p *Producer) internalWrite(ctx context.Context, stream string, id string, values map[string]interface{}, setting *XAddSetting) (string, error) {
 	p.wg.Add(1)
 	defer p.wg.Done()
 
-	reply, err := p.handle.XAdd(&redis.XAddArgs{
-		Stream: stream,
-		ID:     id,
-		Values: values,
-	}).Result()
-	if err != nil {
-		if err != redis.Nil {
+	var client = withContext(p.handle, ctx)
+	for attempt := int64(1); ; attempt++ {
+		reply, err := p.writeOnce(client, stream, id, values, setting)
+		if err == nil || err == redis.Nil {
+			// NOTE: XADD replies nil if the stream doesn't exist with NOMKSTREAM
+			if err == nil {
+				p.metrics.recordWrite(stream, 1, nil)
+			}
+			return reply, err
+		}
+		if err == ErrCircuitOpen || !p.retryPolicy.canRetry(attempt, err) {
+			p.metrics.recordWrite(stream, 0, err)
+			return "", err
+		}
+
+		timer := time.NewTimer(p.retryPolicy.backoff(attempt))
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			p.metrics.recordWrite(stream, 0, err)
 			return "", err
 		}
 	}
-	return reply, nil
+}
+
+// internalWriteBatch sends the requests in a pipeline, or MULTI/EXEC if
+// atomic, the failed requests are retried in the next pipeline. The results
+// are reported by the cmd of each request.
+func (p *Producer) internalWriteBatch(ctx context.Context, requests []*xaddRequest, atomic bool) {
+	var pending = make([]*xaddRequest, 0, len(requests))
+	for _, req := range requests {
+		if req != nil {
+			pending = append(pending, req)
+		}
+	}
+
+	var client = withContext(p.handle, ctx)
+	for attempt := int64(1); ; attempt++ {
+		p.writeBatchOnce(client, pending, atomic)
+
+		var retries []*xaddRequest
+		for _, req := range pending {
+			err := req.cmd.Err()
+			if err == nil || err == redis.Nil || err == ErrCircuitOpen {
+				continue
+			}
+			if p.retryPolicy.canRetry(attempt, err) {
+				retries = append(retries, req)
+			}
+		}
+		if len(retries) == 0 {
+			return
+		}
+
+		timer := time.NewTimer(p.retryPolicy.backoff(attempt))
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		}
+
+		for _, req := range retries {
+			if err := req.reset(); err != nil {
+				return
+			}
+		}
+		pending = retries
+	}
+}
+
+// writeBatchOnce sends the requests through the circuit breaker, the
+// circuit breaker counts the pipeline as a single write.
+func (p *Producer) writeBatchOnce(client redis.UniversalClient, requests []*xaddRequest, atomic bool) {
+	if err := p.breaker.allow(); err != nil {
+		for _, req := range requests {
+			req.cmd.SetErr(err)
+		}
+		return
+	}
+
+	var pipe redis.Pipeliner
+	if atomic {
+		pipe = client.TxPipeline()
+	} else {
+		pipe = client.Pipeline()
+	}
+	defer pipe.Close()
+
+	for _, req := range requests {
+		_ = pipe.Process(req.cmd)
+	}
+	// NOTE: the errors are reported by each command
+	_, err := pipe.Exec()
+	p.reportBreaker(err)
+}
+
+// writeOnce sends XADD through the circuit breaker.
+func (p *Producer) writeOnce(client redis.UniversalClient, stream string, id string, values map[string]interface{}, setting *XAddSetting) (string, error) {
+	cmd, err := newXAddCmd(stream, id, values, setting)
+	if err != nil {
+		return "", err
+	}
+
+	if err := p.breaker.allow(); err != nil {
+		return "", err
+	}
+	_ = client.Process(cmd)
+
+	reply, err := cmd.Result()
+	p.reportBreaker(err)
+	return reply, err
+}
+
+func (p *Producer) reportBreaker(err error) {
+	switch {
+	case errors.Is(err, context.Canceled):
+		p.breaker.release()
+	case err != nil && err != redis.Nil && p.retryPolicy.isRetryable(err):
+		p.breaker.onFailure()
+	default:
+		p.breaker.onSuccess()
+	}
 }
@@ -1,9 +1,26 @@
 package redis
 
-import "log"
+import (
+	"log"
+	"time"
+
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
 
 type ProducerConfig struct {
 	*UniversalOptions
 
 	Logger *log.Logger
+
+	Retention      *StreamRetention // 預設的 stream 保留策略, 套用於每次寫入
+	RetryPolicy    *RetryPolicy     // 寫入失敗時的重試策略, 未設定時不重試
+	CircuitBreaker *CircuitBreaker  // 連續失敗時快速失敗, 未設定時不斷路
+	IdempotencyTTL time.Duration    // WithIdempotencyKey 的保存時間, 預設為 DEFAULT_IDEMPOTENCY_TTL
+	Codec          Codec            // WriteObject 使用的編碼, 預設為 JSONCodec
+
+	Tracer            trace.Tracer                  // 若有設定, XADD 時建立 producer span 並寫入 MessageState
+	TextMapPropagator propagation.TextMapPropagator // 預設為 trace.GetTextMapPropagator()
+	MeterProvider     metric.MeterProvider          // 預設為 otel.GetMeterProvider()
 }
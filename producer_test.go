@@ -0,0 +1,284 @@
+package redis
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func newMiniredisProducer(t *testing.T, config ProducerConfig) (*Producer, *miniredis.Miniredis) {
+	server, err := miniredis.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	config.UniversalOptions = &UniversalOptions{
+		Addrs: []string{server.Addr()},
+	}
+	p, err := NewProducer(&config)
+	if err != nil {
+		server.Close()
+		t.Fatal(err)
+	}
+	return p, server
+}
+
+func TestProducer_WriteContent(t *testing.T) {
+	p, server := newMiniredisProducer(t, ProducerConfig{})
+	defer server.Close()
+	defer p.Close()
+
+	msg := NewMessageContent()
+	msg.Values["name"] = "luffy"
+	msg.Values["age"] = 19
+	_, err := msg.State.Set("correlation-id", "order-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	id, err := p.WriteContent("gotestStream1", msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	messages, err := p.Handle().XRange("gotestStream1", "-", "+").Result()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(messages) != 1 {
+		t.Fatalf("expect %d messages, but got %d messages", 1, len(messages))
+	}
+	if id != messages[0].ID {
+		t.Errorf("Message.ID expect:: %v, got:: %v\n", id, messages[0].ID)
+	}
+	var expectedHeader = "order-1"
+	if v := messages[0].Values["header:correlation-id"]; expectedHeader != v {
+		t.Errorf("Message.Values[header:correlation-id] expect:: %v, got:: %v\n", expectedHeader, v)
+	}
+
+	content := DecodeMessageContent(messages[0].Values)
+	if v := content.State.Value("correlation-id"); expectedHeader != v {
+		t.Errorf("MessageState[correlation-id] expect:: %v, got:: %v\n", expectedHeader, v)
+	}
+	var expectedValues = map[string]interface{}{"name": "luffy", "age": "19"}
+	if len(expectedValues) != len(content.Values) {
+		t.Errorf("MessageContent.Values expect:: %v, got:: %v\n", expectedValues, content.Values)
+	}
+	for k, v := range expectedValues {
+		if content.Values[k] != v {
+			t.Errorf("MessageContent.Values[%s] expect:: %v, got:: %v\n", k, v, content.Values[k])
+		}
+	}
+}
+
+func TestProducer_WriteContent_WithInvalidContent(t *testing.T) {
+	p, server := newMiniredisProducer(t, ProducerConfig{})
+	defer server.Close()
+	defer p.Close()
+
+	// the payload key collides with the MessageState
+	{
+		msg := NewMessageContent()
+		msg.Values["header:correlation-id"] = "order-1"
+
+		_, err := p.WriteContent("gotestStream1", msg)
+		if err == nil {
+			t.Errorf("Producer.WriteContent() should return error with colliding payload key")
+		}
+	}
+
+	// the MessageState value is too large
+	{
+		msg := NewMessageContent()
+		_, err := msg.State.Set("token", strings.Repeat("x", MESSAGE_STATE_VALUE_MAX_SIZE+1))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, err = p.WriteContent("gotestStream1", msg)
+		if err == nil {
+			t.Errorf("Producer.WriteContent() should return error with too large MessageState value")
+		}
+	}
+
+	// the batch message is validated as well
+	{
+		withLargeState := ProduceMessageContentOption(func(msg *MessageContent) error {
+			_, err := msg.State.Set("token", strings.Repeat("x", MESSAGE_STATE_VALUE_MAX_SIZE+1))
+			return err
+		})
+
+		results, err := p.WriteBatchMessages([]BatchMessage{
+			{Stream: "gotestStream1", Values: map[string]interface{}{"header:correlation-id": "order-1"}},
+			{Stream: "gotestStream1", Values: map[string]interface{}{"name": "luffy"},
+				Options: []ProduceMessageOption{withLargeState}},
+		})
+		if err == nil {
+			t.Errorf("Producer.WriteBatchMessages() should return error with invalid content")
+		}
+		for i, r := range results {
+			if r.Err == nil {
+				t.Errorf("WriteResult[%d].Err should not be nil", i)
+			}
+		}
+	}
+
+	if server.Exists("gotestStream1") {
+		t.Errorf("the stream should not be created by the invalid content")
+	}
+}
+
+func TestProducer_WriteContent_WithTracer(t *testing.T) {
+	var (
+		recorder = tracetest.NewSpanRecorder()
+		provider = sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	)
+	defer provider.Shutdown(context.Background())
+
+	var propagator = propagation.TraceContext{}
+
+	p, server := newMiniredisProducer(t, ProducerConfig{
+		Tracer:            provider.Tracer("gotest"),
+		TextMapPropagator: propagator,
+	})
+	defer server.Close()
+	defer p.Close()
+
+	ctx, parent := provider.Tracer("gotest").Start(context.Background(), "parent")
+	parent.End()
+
+	msg := NewMessageContent()
+	msg.Values["name"] = "luffy"
+
+	id, err := p.WriteContent("gotestStream1", msg, WithTracePropagation(ctx, propagator))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	spans := recorder.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("expect %d spans, but got %d spans", 2, len(spans))
+	}
+	span := spans[1]
+	var expectedName = "gotestStream1 publish"
+	if expectedName != span.Name() {
+		t.Errorf("span.Name() expect:: %v, got:: %v\n", expectedName, span.Name())
+	}
+	if parent.SpanContext().SpanID() != span.Parent().SpanID() {
+		t.Errorf("span.Parent() expect:: %v, got:: %v\n", parent.SpanContext().SpanID(), span.Parent().SpanID())
+	}
+
+	// the MessageState carries the producer span
+	messages, err := p.Handle().XRange("gotestStream1", id, id).Result()
+	if err != nil {
+		t.Fatal(err)
+	}
+	content := DecodeMessageContent(messages[0].Values)
+	traceparent, _ := content.State.Value("traceparent").(string)
+	if !strings.Contains(traceparent, span.SpanContext().SpanID().String()) {
+		t.Errorf("MessageState[traceparent] expect span:: %v, got:: %v\n", span.SpanContext().SpanID(), traceparent)
+	}
+}
+
+func TestProducer_Write_WithTracerAndIdempotencyKey(t *testing.T) {
+	var (
+		recorder = tracetest.NewSpanRecorder()
+		provider = sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	)
+	defer provider.Shutdown(context.Background())
+
+	p, server := newMiniredisProducer(t, ProducerConfig{
+		Tracer:            provider.Tracer("gotest"),
+		TextMapPropagator: propagation.TraceContext{},
+		IdempotencyTTL:    time.Minute,
+	})
+	defer server.Close()
+	defer p.Close()
+
+	values := map[string]interface{}{"name": "luffy"}
+	id, err := p.Write("gotestStream1", values, WithIdempotencyKey("order-1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the caller's map is not modified
+	if len(values) != 1 {
+		t.Errorf("the values should not be modified, got:: %v\n", values)
+	}
+
+	// the message carries both the idempotency key and the producer span
+	messages, err := p.Handle().XRange("gotestStream1", id, id).Result()
+	if err != nil {
+		t.Fatal(err)
+	}
+	content := DecodeMessageContent(messages[0].Values)
+	var expectedKey = "order-1"
+	if v := content.State.Value(MESSAGE_STATE_IDEMPOTENCY_KEY); expectedKey != v {
+		t.Errorf("MessageState[%s] expect:: %v, got:: %v\n", MESSAGE_STATE_IDEMPOTENCY_KEY, expectedKey, v)
+	}
+	spans := recorder.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("expect %d spans, but got %d spans", 1, len(spans))
+	}
+	traceparent, _ := content.State.Value("traceparent").(string)
+	if !strings.Contains(traceparent, spans[0].SpanContext().SpanID().String()) {
+		t.Errorf("MessageState[traceparent] expect span:: %v, got:: %v\n", spans[0].SpanContext().SpanID(), traceparent)
+	}
+}
+
+func TestProducer_WriteObject(t *testing.T) {
+	p, server := newMiniredisProducer(t, ProducerConfig{
+		Codec: MessagePackCodec{},
+	})
+	defer server.Close()
+	defer p.Close()
+
+	_, err := p.WriteObject("gotestStream1", &gotestOrder{ID: "order-1", Amount: 100})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the mixed-format stream
+	msg, err := EncodeMessageContent(JSONCodec{}, &gotestOrder{ID: "order-2", Amount: 200})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = p.WriteContent("gotestStream1", msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	messages, err := p.Handle().XRange("gotestStream1", "-", "+").Result()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(messages) != 2 {
+		t.Fatalf("expect %d messages, but got %d messages", 2, len(messages))
+	}
+
+	var expectedOrders = []gotestOrder{
+		{ID: "order-1", Amount: 100},
+		{ID: "order-2", Amount: 200},
+	}
+	for i := range messages {
+		message := &Message{
+			XMessage: &messages[i],
+			Stream:   "gotestStream1",
+		}
+
+		var order gotestOrder
+		err := message.Decode(&order)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if expectedOrders[i] != order {
+			t.Errorf("Message.Decode() expect:: %v, got:: %v\n", expectedOrders[i], order)
+		}
+	}
+}
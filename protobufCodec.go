@@ -0,0 +1,35 @@
+package redis
+
+import (
+	"fmt"
+
+	"google.golang.org/protobuf/proto"
+)
+
+var _ Codec = ProtobufCodec{}
+
+// ProtobufCodec encodes the proto.Message only.
+type ProtobufCodec struct{}
+
+// Name implements Codec.
+func (ProtobufCodec) Name() string {
+	return "protobuf"
+}
+
+// Marshal implements Codec.
+func (ProtobufCodec) Marshal(v interface{}) ([]byte, error) {
+	m, ok := v.(proto.Message)
+	if !ok {
+		return nil, fmt.Errorf("got %T, wanted proto.Message", v)
+	}
+	return proto.Marshal(m)
+}
+
+// Unmarshal implements Codec.
+func (ProtobufCodec) Unmarshal(data []byte, v interface{}) error {
+	m, ok := v.(proto.Message)
+	if !ok {
+		return fmt.Errorf("got %T, wanted proto.Message", v)
+	}
+	return proto.Unmarshal(data, m)
+}
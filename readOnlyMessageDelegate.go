@@ -0,0 +1,21 @@
+package redis
+
+import "time"
+
+var _ MessageDelegate = new(readOnlyMessageDelegate)
+
+// readOnlyMessageDelegate is the MessageDelegate of the messages read by
+// AdminClient, the messages are not delivered to any group.
+type readOnlyMessageDelegate struct{}
+
+// OnAck implements MessageDelegate.
+func (readOnlyMessageDelegate) OnAck(msg *Message) {}
+
+// OnDel implements MessageDelegate.
+func (readOnlyMessageDelegate) OnDel(msg *Message) {}
+
+// OnNack implements MessageDelegate.
+func (readOnlyMessageDelegate) OnNack(msg *Message, delay time.Duration) {}
+
+// OnRetry implements MessageDelegate.
+func (readOnlyMessageDelegate) OnRetry(msg *Message) {}
@@ -0,0 +1,49 @@
+package redis
+
+import (
+	"bufio"
+	"strconv"
+	"strings"
+)
+
+type redisServerVersion struct {
+	major int
+	minor int
+	patch int
+}
+
+// parseRedisServerVersion parses the redis_version field from the
+// reply of INFO SERVER.
+func parseRedisServerVersion(info string) (redisServerVersion, bool) {
+	scanner := bufio.NewScanner(strings.NewReader(info))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+
+		value, ok := strings.CutPrefix(line, "redis_version:")
+		if !ok {
+			continue
+		}
+
+		var (
+			version redisServerVersion
+			parts   = strings.SplitN(value, ".", 3)
+			numbers = []*int{&version.major, &version.minor, &version.patch}
+		)
+		for i, part := range parts {
+			n, err := strconv.Atoi(part)
+			if err != nil {
+				return redisServerVersion{}, false
+			}
+			*numbers[i] = n
+		}
+		return version, true
+	}
+	return redisServerVersion{}, false
+}
+
+func (v redisServerVersion) atLeast(major, minor int) bool {
+	if v.major != major {
+		return v.major > major
+	}
+	return v.minor >= minor
+}
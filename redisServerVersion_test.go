@@ -0,0 +1,49 @@
+package redis
+
+import "testing"
+
+func TestParseRedisServerVersion(t *testing.T) {
+	info := "# Server\r\n" +
+		"redis_version:6.2.14\r\n" +
+		"redis_git_sha1:00000000\r\n" +
+		"redis_mode:standalone\r\n"
+
+	version, ok := parseRedisServerVersion(info)
+	if !ok {
+		t.Fatalf("parseRedisServerVersion() should be ok")
+	}
+
+	var expectedVersion = redisServerVersion{major: 6, minor: 2, patch: 14}
+	if expectedVersion != version {
+		t.Errorf("redisServerVersion expect:: %+v, got:: %+v\n", expectedVersion, version)
+	}
+
+	expectedAndGot := [][]bool{
+		// run atLeast()         , expected
+		{version.atLeast(5, 0), true},
+		{version.atLeast(6, 0), true},
+		{version.atLeast(6, 2), true},
+		{version.atLeast(6, 3), false},
+		{version.atLeast(7, 0), false},
+	}
+
+	for i, v := range expectedAndGot {
+		expected, got := v[1], v[0]
+		if expected != got {
+			t.Errorf("assert atLeast() at %d :: expected %+v, got %+v", i, expected, got)
+		}
+	}
+}
+
+func TestParseRedisServerVersion_WithInvalidInfo(t *testing.T) {
+	for _, info := range []string{
+		"",
+		"# Server\r\nredis_mode:standalone\r\n",
+		"# Server\r\nredis_version:unknown\r\n",
+	} {
+		_, ok := parseRedisServerVersion(info)
+		if ok {
+			t.Errorf("parseRedisServerVersion(%q) should not be ok", info)
+		}
+	}
+}
@@ -6,12 +6,17 @@ import (
 	"io"
 	"os"
 	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	redis "github.com/Bofry/lib-redis-stream"
+	"github.com/Bofry/lib-redis-stream/tracing"
 	"github.com/joho/godotenv"
+	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
 	"go.opentelemetry.io/otel/trace"
 )
 
@@ -292,3 +297,958 @@ func TestProducer_Write(t *testing.T) {
 		}
 	}
 }
+
+func TestProducer_Write_WithRetention(t *testing.T) {
+	conf := redis.ProducerConfig{
+		UniversalOptions: &redis.UniversalOptions{
+			Addrs: __TEST_REDIS_SERVERS,
+			DB:    0,
+		},
+		Retention: &redis.StreamRetention{
+			MaxLen: 3,
+		},
+	}
+	p, err := redis.NewProducer(&conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	client := p.Handle()
+
+	/* NOTE: delete if existed !!
+	DEL TestProducer_Write_WithRetention
+	*/
+	_, err = client.Del("TestProducer_Write_WithRetention").Result()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Del("TestProducer_Write_WithRetention")
+
+	var ids []string
+	for _, name := range []string{"luffy", "nami", "zoro", "sanji", "usopp"} {
+		id, err := p.Write("TestProducer_Write_WithRetention", map[string]interface{}{"name": name})
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, id)
+	}
+
+	// the default retention
+	{
+		msgCnt, err := client.XLen("TestProducer_Write_WithRetention").Result()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var expectedMsgCnt int64 = 3
+		if msgCnt != expectedMsgCnt {
+			t.Errorf("expect %d messages, but got %d messages", expectedMsgCnt, msgCnt)
+		}
+	}
+
+	// override the default retention
+	{
+		_, err = p.Write("TestProducer_Write_WithRetention", map[string]interface{}{"name": "chopper"},
+			redis.WithMinID(ids[4]))
+		if err != nil {
+			t.Fatal(err)
+		}
+		msgCnt, err := client.XLen("TestProducer_Write_WithRetention").Result()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var expectedMsgCnt int64 = 2
+		if msgCnt != expectedMsgCnt {
+			t.Errorf("expect %d messages, but got %d messages", expectedMsgCnt, msgCnt)
+		}
+	}
+
+	// disable the default retention
+	{
+		for _, name := range []string{"robin", "franky"} {
+			_, err = p.Write("TestProducer_Write_WithRetention", map[string]interface{}{"name": name},
+				redis.WithoutTrim())
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+		msgCnt, err := client.XLen("TestProducer_Write_WithRetention").Result()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var expectedMsgCnt int64 = 4
+		if msgCnt != expectedMsgCnt {
+			t.Errorf("expect %d messages, but got %d messages", expectedMsgCnt, msgCnt)
+		}
+	}
+
+	// the invalid retention
+	{
+		_, err = redis.NewProducer(&redis.ProducerConfig{
+			UniversalOptions: conf.UniversalOptions,
+			Retention: &redis.StreamRetention{
+				MaxLen: 3,
+				MaxAge: time.Hour,
+			},
+		})
+		if err == nil {
+			t.Errorf("NewProducer() should return error with invalid retention")
+		}
+	}
+}
+
+func TestProducer_Write_WithTracer(t *testing.T) {
+	const stream = "TestProducer_Write_WithTracer"
+
+	admin, err := redis.NewAdminClient(&redis.UniversalOptions{
+		Addrs: __TEST_REDIS_SERVERS,
+		DB:    0,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer admin.Close()
+
+	/*
+		DEL TestProducer_Write_WithTracer
+	*/
+	defer admin.Handle().Del(stream)
+
+	var (
+		recorder = tracetest.NewSpanRecorder()
+		provider = sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	)
+	defer provider.Shutdown(context.Background())
+
+	conf := redis.ProducerConfig{
+		UniversalOptions: &redis.UniversalOptions{
+			Addrs: __TEST_REDIS_SERVERS,
+			DB:    0,
+		},
+		Tracer:            provider.Tracer("gotest"),
+		TextMapPropagator: __TEST_PROPAGATOR,
+	}
+	p, err := redis.NewProducer(&conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	values := map[string]interface{}{"name": "luffy", "age": 19}
+	id, err := p.Write(stream, values)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(values) != 2 {
+		t.Errorf("Producer.Write() should not modify the values, got:: %v\n", values)
+	}
+
+	spans := recorder.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("expect %d spans, but got %d spans", 1, len(spans))
+	}
+	span := spans[0]
+	if span.SpanKind() != trace.SpanKindProducer {
+		t.Errorf("span.SpanKind() expect:: %v, got:: %v\n", trace.SpanKindProducer, span.SpanKind())
+	}
+
+	var attrs = make(map[attribute.Key]string)
+	for _, kv := range span.Attributes() {
+		attrs[kv.Key] = kv.Value.Emit()
+	}
+	expectedAttrs := map[attribute.Key]string{
+		"messaging.system":           "redis",
+		"messaging.operation":        "publish",
+		"messaging.destination.name": stream,
+		"messaging.message.id":       id,
+	}
+	for k, v := range expectedAttrs {
+		if attrs[k] != v {
+			t.Errorf("span attribute %s expect:: %v, got:: %v\n", k, v, attrs[k])
+		}
+	}
+	if _, ok := attrs["messaging.message.payload_size_bytes"]; !ok {
+		t.Errorf("span attribute %s should be set", "messaging.message.payload_size_bytes")
+	}
+
+	// the span context is injected into the MessageState
+	messages, err := admin.Handle().XRange(stream, id, id).Result()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(messages) != 1 {
+		t.Fatalf("expect %d messages, but got %d messages", 1, len(messages))
+	}
+	content := redis.DecodeMessageContent(messages[0].Values)
+	ctx := __TEST_PROPAGATOR.Extract(context.Background(), tracing.NewMessageStateCarrier(&content.State))
+	sc := trace.SpanContextFromContext(ctx)
+	if sc.SpanID() != span.SpanContext().SpanID() {
+		t.Errorf("injected span ID expect:: %v, got:: %v\n", span.SpanContext().SpanID(), sc.SpanID())
+	}
+}
+
+func TestProducer_WriteBatch(t *testing.T) {
+	conf := redis.ProducerConfig{
+		UniversalOptions: &redis.UniversalOptions{
+			Addrs: __TEST_REDIS_SERVERS,
+			DB:    0,
+		},
+	}
+	p, err := redis.NewProducer(&conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	client := p.Handle()
+
+	/*
+		DEL TestProducer_WriteBatch_1
+		DEL TestProducer_WriteBatch_2
+	*/
+	_, err = client.Del("TestProducer_WriteBatch_1", "TestProducer_WriteBatch_2").Result()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Del("TestProducer_WriteBatch_1", "TestProducer_WriteBatch_2")
+
+	// single stream
+	{
+		results, err := p.WriteBatch("TestProducer_WriteBatch_1", []map[string]interface{}{
+			{"name": "luffy", "age": 19},
+			{"name": "nami", "age": 21},
+			{"name": "zoro", "age": 21},
+		}, redis.WithTracePropagation(__TEST_CONTEXT, __TEST_PROPAGATOR))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(results) != 3 {
+			t.Fatalf("expect %d results, but got %d results", 3, len(results))
+		}
+
+		messages, err := client.XRange("TestProducer_WriteBatch_1", "-", "+").Result()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(messages) != 3 {
+			t.Fatalf("expect %d messages, but got %d messages", 3, len(messages))
+		}
+		for i, msg := range messages {
+			if results[i].ID != msg.ID {
+				t.Errorf("WriteResult.ID expect:: %v, got:: %v\n", msg.ID, results[i].ID)
+			}
+		}
+		var expectedName = "nami"
+		if v := messages[1].Values["name"]; expectedName != v {
+			t.Errorf("Message.Values[name] expect:: %v, got:: %v\n", expectedName, v)
+		}
+		// the ProduceMessageContentOption is applied to each message
+		for _, msg := range messages {
+			if _, ok := msg.Values["header:traceparent"]; !ok {
+				t.Errorf("Message.Values should contain traceparent, got:: %v\n", msg.Values)
+			}
+		}
+	}
+
+	// multiple streams with the error of the message
+	{
+		results, err := p.WriteBatchMessages([]redis.BatchMessage{
+			{Stream: "TestProducer_WriteBatch_2", Values: map[string]interface{}{"name": "roger"}},
+			{Stream: "TestProducer_WriteBatch_1", Values: map[string]interface{}{"name": "sanji"},
+				Options: []redis.ProduceMessageOption{redis.WithMessageID("1-1")}},
+			{Stream: "TestProducer_WriteBatch_2", Values: map[string]interface{}{"name": "ace"}},
+		})
+		if err == nil {
+			t.Errorf("Producer.WriteBatchMessages() should return error")
+		}
+		if len(results) != 3 {
+			t.Fatalf("expect %d results, but got %d results", 3, len(results))
+		}
+		for i, expectedErr := range []bool{false, true, false} {
+			if expectedErr != (results[i].Err != nil) {
+				t.Errorf("WriteResult[%d].Err got:: %v\n", i, results[i].Err)
+			}
+		}
+
+		msgCnt, err := client.XLen("TestProducer_WriteBatch_2").Result()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var expectedMsgCnt int64 = 2
+		if msgCnt != expectedMsgCnt {
+			t.Errorf("expect %d messages, but got %d messages", expectedMsgCnt, msgCnt)
+		}
+	}
+
+	// atomic
+	{
+		results, err := p.WriteBatch("TestProducer_WriteBatch_2", []map[string]interface{}{
+			{"name": "sabo"},
+			{"name": "dragon"},
+		}, redis.WithAtomicBatch(), redis.WithMaxLen(3))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i, r := range results {
+			if len(r.ID) == 0 {
+				t.Errorf("WriteResult[%d].ID should not be empty", i)
+			}
+		}
+
+		msgCnt, err := client.XLen("TestProducer_WriteBatch_2").Result()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var expectedMsgCnt int64 = 3
+		if msgCnt != expectedMsgCnt {
+			t.Errorf("expect %d messages, but got %d messages", expectedMsgCnt, msgCnt)
+		}
+	}
+
+	// atomic batch is aborted if any message cannot be prepared
+	{
+		_, err := p.WriteBatchMessages([]redis.BatchMessage{
+			{Stream: "TestProducer_WriteBatch_2", Values: map[string]interface{}{"name": "garp"}},
+			{Stream: "TestProducer_WriteBatch_2", Values: map[string]interface{}{"name": "sengoku"},
+				Options: []redis.ProduceMessageOption{redis.WithMaxLen(-1)}},
+		}, redis.WithAtomicBatch())
+		if err == nil {
+			t.Errorf("Producer.WriteBatchMessages() should return error")
+		}
+
+		msgCnt, err := client.XLen("TestProducer_WriteBatch_2").Result()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var expectedMsgCnt int64 = 3
+		if msgCnt != expectedMsgCnt {
+			t.Errorf("expect %d messages, but got %d messages", expectedMsgCnt, msgCnt)
+		}
+	}
+}
+
+func TestProducer_WriteBatch_WithRetryPolicy(t *testing.T) {
+	const stream = "TestProducer_WriteBatch_WithRetryPolicy"
+
+	var retries int32
+
+	p, err := redis.NewProducer(&redis.ProducerConfig{
+		UniversalOptions: &redis.UniversalOptions{
+			Addrs: __TEST_REDIS_SERVERS,
+			DB:    0,
+		},
+		RetryPolicy: &redis.RetryPolicy{
+			MaxAttempts: 3,
+			Backoff: redis.BackoffFunc(func(attempt int64) time.Duration {
+				atomic.AddInt32(&retries, 1)
+				return time.Millisecond
+			}),
+			// NOTE: take all the errors as retryable for test
+			Retryable: func(err error) bool { return true },
+		},
+		CircuitBreaker: &redis.CircuitBreaker{
+			FailureThreshold: 3,
+			OpenTimeout:      time.Minute,
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+	defer p.Handle().Del(stream)
+
+	// only the failed message is retried, the ID 0-0 is always rejected
+	{
+		results, err := p.WriteBatchMessages([]redis.BatchMessage{
+			{Stream: stream, Values: map[string]interface{}{"name": "luffy"}},
+			{Stream: stream, Values: map[string]interface{}{"name": "nami"},
+				Options: []redis.ProduceMessageOption{redis.WithMessageID("0-0")}},
+		})
+		if err == nil {
+			t.Errorf("Producer.WriteBatchMessages() should return error")
+		}
+		if results[0].Err != nil {
+			t.Errorf("WriteResult[0].Err expect:: %v, got:: %v\n", nil, results[0].Err)
+		}
+		var expectedRetries int32 = 2
+		if got := atomic.LoadInt32(&retries); expectedRetries != got {
+			t.Errorf("expect %d retries, but got %d retries", expectedRetries, got)
+		}
+
+		msgCnt, err := p.Handle().XLen(stream).Result()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var expectedMsgCnt int64 = 1
+		if msgCnt != expectedMsgCnt {
+			t.Errorf("expect %d messages, but got %d messages", expectedMsgCnt, msgCnt)
+		}
+	}
+
+	// the circuit is open after 3 consecutive failures
+	{
+		results, err := p.WriteBatch(stream, []map[string]interface{}{
+			{"name": "zoro"},
+		})
+		if err != redis.ErrCircuitOpen {
+			t.Errorf("Producer.WriteBatch() expect:: %v, got:: %v\n", redis.ErrCircuitOpen, err)
+		}
+		if results[0].Err != redis.ErrCircuitOpen {
+			t.Errorf("WriteResult[0].Err expect:: %v, got:: %v\n", redis.ErrCircuitOpen, results[0].Err)
+		}
+	}
+
+	// the idempotency key cannot be applied to the whole batch
+	{
+		_, err := p.WriteBatch(stream, []map[string]interface{}{
+			{"name": "sanji"},
+			{"name": "usopp"},
+		}, redis.WithIdempotencyKey("order-1"))
+		if err != redis.ErrBatchIdempotencyKey {
+			t.Errorf("Producer.WriteBatch() expect:: %v, got:: %v\n", redis.ErrBatchIdempotencyKey, err)
+		}
+	}
+}
+
+func TestProducer_WriteBatchContext(t *testing.T) {
+	const stream = "TestProducer_WriteBatchContext"
+
+	p, err := redis.NewProducer(&redis.ProducerConfig{
+		UniversalOptions: &redis.UniversalOptions{
+			Addrs: __TEST_REDIS_SERVERS,
+			DB:    0,
+		},
+		RetryPolicy: &redis.RetryPolicy{
+			MaxAttempts: 3,
+			Backoff:     redis.ConstantBackoff(time.Minute),
+			// NOTE: take all the errors as retryable for test
+			Retryable: func(err error) bool { return true },
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+	defer p.Handle().Del(stream)
+
+	// the retries are stopped by the deadline of the context, the ID 0-0
+	// is always rejected
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	results, err := p.WriteBatchContext(ctx, stream, []map[string]interface{}{
+		{"name": "luffy"},
+	}, redis.WithMessageID("0-0"))
+	if err == nil {
+		t.Errorf("Producer.WriteBatchContext() should return error")
+	}
+	if results[0].Err == nil {
+		t.Errorf("WriteResult[0].Err should not be nil")
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Producer.WriteBatchContext() should return after the deadline, but took %v", elapsed)
+	}
+}
+
+func TestAsyncProducer_WriteAsync(t *testing.T) {
+	const stream = "TestAsyncProducer_WriteAsync"
+
+	p, err := redis.NewAsyncProducer(&redis.AsyncProducerConfig{
+		ProducerConfig: redis.ProducerConfig{
+			UniversalOptions: &redis.UniversalOptions{
+				Addrs: __TEST_REDIS_SERVERS,
+				DB:    0,
+			},
+		},
+		BatchSize:        2,
+		LingerTime:       time.Hour,
+		ReturnDeliveries: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := p.Handle()
+
+	/*
+		DEL TestAsyncProducer_WriteAsync
+	*/
+	_, err = client.Del(stream).Result()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []string{"luffy", "nami", "zoro"} {
+		err = p.WriteAsync(stream, map[string]interface{}{"name": name})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// the full batch is sent without lingering
+	for i := 0; i < 2; i++ {
+		select {
+		case report := <-p.Deliveries():
+			if report.Err != nil {
+				t.Fatal(report.Err)
+			}
+			if len(report.ID) == 0 {
+				t.Errorf("DeliveryReport.ID should not be empty")
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("expect delivery report of the full batch")
+		}
+	}
+
+	// the rest are sent by Flush()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	err = p.Flush(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	report := <-p.Deliveries()
+	if v := report.Values["name"]; v != "zoro" {
+		t.Errorf("DeliveryReport.Values[name] expect:: %v, got:: %v\n", "zoro", v)
+	}
+
+	// Close() flushes the queued messages
+	err = p.WriteAsync(stream, map[string]interface{}{"name": "sanji"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.Close()
+
+	var reports int
+	for range p.Deliveries() {
+		reports++
+	}
+	if reports != 1 {
+		t.Errorf("expect %d delivery reports, but got %d delivery reports", 1, reports)
+	}
+
+	err = p.WriteAsync(stream, map[string]interface{}{"name": "usopp"})
+	if err == nil {
+		t.Errorf("AsyncProducer.WriteAsync() should return error after closed")
+	}
+
+	// assert
+	{
+		admin, err := redis.NewAdminClient(&redis.UniversalOptions{
+			Addrs: __TEST_REDIS_SERVERS,
+			DB:    0,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer admin.Close()
+		defer admin.Handle().Del(stream)
+
+		msgCnt, err := admin.Length(stream)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var expectedMsgCnt int64 = 4
+		if msgCnt != expectedMsgCnt {
+			t.Errorf("expect %d messages, but got %d messages", expectedMsgCnt, msgCnt)
+		}
+	}
+}
+
+func TestAsyncProducer_WithBackpressure(t *testing.T) {
+	const stream = "TestAsyncProducer_WithBackpressure"
+
+	admin, err := redis.NewAdminClient(&redis.UniversalOptions{
+		Addrs: __TEST_REDIS_SERVERS,
+		DB:    0,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer admin.Close()
+
+	/*
+		DEL TestAsyncProducer_WithBackpressure
+	*/
+	_, err = admin.Handle().Del(stream).Result()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer admin.Handle().Del(stream)
+
+	for _, backpressure := range []redis.BackpressurePolicy{redis.BackpressureError, redis.BackpressureDrop} {
+		var (
+			entered = make(chan struct{})
+			release = make(chan struct{})
+			dropped int32
+		)
+
+		p, err := redis.NewAsyncProducer(&redis.AsyncProducerConfig{
+			ProducerConfig: redis.ProducerConfig{
+				UniversalOptions: &redis.UniversalOptions{
+					Addrs: __TEST_REDIS_SERVERS,
+					DB:    0,
+				},
+			},
+			QueueSize:    1,
+			BatchSize:    1,
+			Backpressure: backpressure,
+			DeliveryHandler: func(report *redis.DeliveryReport) {
+				if report.Err == redis.ErrQueueFull {
+					atomic.AddInt32(&dropped, 1)
+					return
+				}
+				// block the flusher on the first message
+				if report.Values["name"] == "luffy" {
+					close(entered)
+					<-release
+				}
+			},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = p.WriteAsync(stream, map[string]interface{}{"name": "luffy"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		<-entered
+
+		err = p.WriteAsync(stream, map[string]interface{}{"name": "nami"})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// the queue is full
+		err = p.WriteAsync(stream, map[string]interface{}{"name": "zoro"})
+		switch backpressure {
+		case redis.BackpressureError:
+			if err != redis.ErrQueueFull {
+				t.Errorf("AsyncProducer.WriteAsync() expect:: %v, got:: %v\n", redis.ErrQueueFull, err)
+			}
+		case redis.BackpressureDrop:
+			if err != nil {
+				t.Errorf("AsyncProducer.WriteAsync() expect:: %v, got:: %v\n", nil, err)
+			}
+			if atomic.LoadInt32(&dropped) != 1 {
+				t.Errorf("expect %d dropped messages, but got %d dropped messages", 1, dropped)
+			}
+		}
+
+		close(release)
+		p.Close()
+	}
+
+	msgCnt, err := admin.Length(stream)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var expectedMsgCnt int64 = 4
+	if msgCnt != expectedMsgCnt {
+		t.Errorf("expect %d messages, but got %d messages", expectedMsgCnt, msgCnt)
+	}
+}
+
+func TestAsyncProducer_Close(t *testing.T) {
+	const stream = "TestAsyncProducer_Close"
+
+	admin, err := redis.NewAdminClient(&redis.UniversalOptions{
+		Addrs: __TEST_REDIS_SERVERS,
+		DB:    0,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer admin.Close()
+
+	/*
+		DEL TestAsyncProducer_Close
+	*/
+	_, err = admin.Handle().Del(stream).Result()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer admin.Handle().Del(stream)
+
+	// the blocked writers are released by Close
+	{
+		var (
+			entered = make(chan struct{})
+			release = make(chan struct{})
+		)
+
+		p, err := redis.NewAsyncProducer(&redis.AsyncProducerConfig{
+			ProducerConfig: redis.ProducerConfig{
+				UniversalOptions: &redis.UniversalOptions{
+					Addrs: __TEST_REDIS_SERVERS,
+					DB:    0,
+				},
+			},
+			QueueSize:    1,
+			BatchSize:    1,
+			Backpressure: redis.BackpressureBlock,
+			DeliveryHandler: func(report *redis.DeliveryReport) {
+				// block the flusher on the first message
+				if report.Values["name"] == "luffy" {
+					close(entered)
+					<-release
+				}
+			},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = p.WriteAsync(stream, map[string]interface{}{"name": "luffy"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		<-entered
+
+		err = p.WriteAsync(stream, map[string]interface{}{"name": "nami"})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// the queue is full
+		blocked := make(chan error)
+		go func() {
+			blocked <- p.WriteAsync(stream, map[string]interface{}{"name": "zoro"})
+		}()
+		// wait for the writer blocked on the queue
+		time.Sleep(50 * time.Millisecond)
+
+		closed := make(chan struct{})
+		go func() {
+			p.Close()
+			close(closed)
+		}()
+
+		select {
+		case err := <-blocked:
+			if err == nil {
+				t.Errorf("AsyncProducer.WriteAsync() should return error after closed")
+			}
+		case <-time.After(time.Second):
+			t.Fatal("AsyncProducer.WriteAsync() is not released by Close()")
+		}
+
+		close(release)
+		select {
+		case <-closed:
+		case <-time.After(time.Second):
+			t.Fatal("AsyncProducer.Close() timeout")
+		}
+	}
+
+	// the unread Deliveries doesn't stall the flusher
+	{
+		p, err := redis.NewAsyncProducer(&redis.AsyncProducerConfig{
+			ProducerConfig: redis.ProducerConfig{
+				UniversalOptions: &redis.UniversalOptions{
+					Addrs: __TEST_REDIS_SERVERS,
+					DB:    0,
+				},
+			},
+			QueueSize:        1,
+			BatchSize:        1,
+			Backpressure:     redis.BackpressureBlock,
+			ReturnDeliveries: true,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		done := make(chan struct{})
+		go func() {
+			for i := 0; i < 8; i++ {
+				err := p.WriteAsync(stream, map[string]interface{}{"name": fmt.Sprintf("marine-%d", i)})
+				if err != nil {
+					t.Error(err)
+				}
+			}
+			p.Close()
+			close(done)
+		}()
+
+		select {
+		case <-done:
+		case <-time.After(5 * time.Second):
+			t.Fatal("AsyncProducer.Close() timeout")
+		}
+
+		// the reports exceeding the capacity of Deliveries() are counted
+		var reports int64
+		for range p.Deliveries() {
+			reports++
+		}
+		var expectedReports int64 = 1
+		if expectedReports != reports {
+			t.Errorf("expect %d delivery reports, but got %d delivery reports", expectedReports, reports)
+		}
+		var expectedDropped int64 = 7
+		if dropped := p.DroppedDeliveries(); expectedDropped != dropped {
+			t.Errorf("AsyncProducer.DroppedDeliveries() expect:: %v, got:: %v\n", expectedDropped, dropped)
+		}
+	}
+
+	msgCnt, err := admin.Length(stream)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var expectedMsgCnt int64 = 10
+	if msgCnt != expectedMsgCnt {
+		t.Errorf("expect %d messages, but got %d messages", expectedMsgCnt, msgCnt)
+	}
+}
+
+func TestProducer_WriteContext_WithRetryPolicy(t *testing.T) {
+	const stream = "TestProducer_WriteContext_WithRetryPolicy"
+
+	var retries int32
+
+	breaker := &redis.CircuitBreaker{
+		FailureThreshold: 4,
+		OpenTimeout:      100 * time.Millisecond,
+	}
+	p, err := redis.NewProducer(&redis.ProducerConfig{
+		UniversalOptions: &redis.UniversalOptions{
+			Addrs: __TEST_REDIS_SERVERS,
+			DB:    0,
+		},
+		RetryPolicy: &redis.RetryPolicy{
+			MaxAttempts: 3,
+			Backoff: redis.BackoffFunc(func(attempt int64) time.Duration {
+				atomic.AddInt32(&retries, 1)
+				return time.Millisecond
+			}),
+			// NOTE: take all the errors as retryable for test
+			Retryable: func(err error) bool { return true },
+		},
+		CircuitBreaker: breaker,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+	defer p.Handle().Del(stream)
+
+	// the ID 0-0 is always rejected
+	_, err = p.Write(stream, map[string]interface{}{"name": "luffy"}, redis.WithMessageID("0-0"))
+	if err == nil {
+		t.Errorf("Producer.Write() should return error")
+	}
+	var expectedRetries int32 = 2
+	if got := atomic.LoadInt32(&retries); expectedRetries != got {
+		t.Errorf("expect %d retries, but got %d retries", expectedRetries, got)
+	}
+
+	// the circuit is open after 4 consecutive failures
+	_, err = p.Write(stream, map[string]interface{}{"name": "nami"}, redis.WithMessageID("0-0"))
+	if err != redis.ErrCircuitOpen {
+		t.Errorf("Producer.Write() expect:: %v, got:: %v\n", redis.ErrCircuitOpen, err)
+	}
+	if state := p.CircuitState(); state != redis.CircuitOpen {
+		t.Errorf("Producer.CircuitState() expect:: %v, got:: %v\n", redis.CircuitOpen, state)
+	}
+
+	// the trial write closes the circuit
+	time.Sleep(120 * time.Millisecond)
+	_, err = p.Write(stream, map[string]interface{}{"name": "zoro"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state := p.CircuitState(); state != redis.CircuitClosed {
+		t.Errorf("Producer.CircuitState() expect:: %v, got:: %v\n", redis.CircuitClosed, state)
+	}
+
+	// the canceled context
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = p.WriteContext(ctx, stream, map[string]interface{}{"name": "sanji"})
+	if err == nil {
+		t.Errorf("Producer.WriteContext() should return error with canceled context")
+	}
+	if state := p.CircuitState(); state != redis.CircuitClosed {
+		t.Errorf("Producer.CircuitState() expect:: %v, got:: %v\n", redis.CircuitClosed, state)
+	}
+}
+
+func TestProducer_Write_WithIdempotencyKey(t *testing.T) {
+	const stream = "TestProducer_Write_WithIdempotencyKey"
+
+	p, err := redis.NewProducer(&redis.ProducerConfig{
+		UniversalOptions: &redis.UniversalOptions{
+			Addrs: __TEST_REDIS_SERVERS,
+			DB:    0,
+		},
+		IdempotencyTTL: time.Minute,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	client := p.Handle()
+
+	/*
+		DEL TestProducer_Write_WithIdempotencyKey
+		DEL {TestProducer_Write_WithIdempotencyKey}:idempotency:produced:order-1
+		DEL {TestProducer_Write_WithIdempotencyKey}:idempotency:produced:order-2
+	*/
+	var keys = []string{
+		stream,
+		"{" + stream + "}:idempotency:produced:order-1",
+		"{" + stream + "}:idempotency:produced:order-2",
+	}
+	_, err = client.Del(keys...).Result()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Del(keys...)
+
+	id1, err := p.Write(stream, map[string]interface{}{"name": "luffy"}, redis.WithIdempotencyKey("order-1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	id2, err := p.Write(stream, map[string]interface{}{"name": "luffy"}, redis.WithIdempotencyKey("order-1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id1 != id2 {
+		t.Errorf("Producer.Write() expect:: %v, got:: %v\n", id1, id2)
+	}
+
+	results, err := p.WriteBatchMessages([]redis.BatchMessage{
+		{Stream: stream, Values: map[string]interface{}{"name": "nami"},
+			Options: []redis.ProduceMessageOption{redis.WithIdempotencyKey("order-1")}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id1 != results[0].ID {
+		t.Errorf("Producer.WriteBatchMessages() expect:: %v, got:: %v\n", id1, results[0].ID)
+	}
+
+	id3, err := p.Write(stream, map[string]interface{}{"name": "zoro"}, redis.WithIdempotencyKey("order-2"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id1 == id3 {
+		t.Errorf("Producer.Write() should write the message with another idempotency key")
+	}
+
+	messages, err := client.XRange(stream, "-", "+").Result()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(messages) != 2 {
+		t.Fatalf("expect %d messages, but got %d messages", 2, len(messages))
+	}
+	content := redis.DecodeMessageContent(messages[0].Values)
+	if v := content.State.Value(redis.MESSAGE_STATE_IDEMPOTENCY_KEY); v != "order-1" {
+		t.Errorf("MessageState[%s] expect:: %v, got:: %v\n", redis.MESSAGE_STATE_IDEMPOTENCY_KEY, "order-1", v)
+	}
+}
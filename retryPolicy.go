@@ -0,0 +1,33 @@
+package redis
+
+import "time"
+
+// RetryPolicy retries the failed writes of the Producer.
+// NOTE: the message might be written twice if the reply of XADD is lost,
+// see also WithIdempotencyKey().
+type RetryPolicy struct {
+	MaxAttempts int64                // 包含首次寫入的最大嘗試次數, 小於等於 1 時不重試
+	Backoff     BackoffPolicy        // 每次重試前的等待時間, 預設為 defaultErrorBackoff
+	Retryable   func(err error) bool // 判斷錯誤是否可重試, 預設為網路錯誤及 LOADING, TRYAGAIN 等暫時性錯誤
+}
+
+func (p *RetryPolicy) canRetry(attempt int64, err error) bool {
+	if p == nil || attempt >= p.MaxAttempts {
+		return false
+	}
+	return p.isRetryable(err)
+}
+
+func (p *RetryPolicy) isRetryable(err error) bool {
+	if p != nil && p.Retryable != nil {
+		return p.Retryable(err)
+	}
+	return isTransientError(err)
+}
+
+func (p *RetryPolicy) backoff(attempt int64) time.Duration {
+	if p.Backoff != nil {
+		return p.Backoff.Backoff(attempt)
+	}
+	return defaultErrorBackoff.Backoff(attempt)
+}
@@ -0,0 +1,92 @@
+package redis
+
+import (
+	"sync"
+	"time"
+)
+
+type retryScheduler struct {
+	mutex   sync.Mutex
+	entries map[string]map[string]time.Time // stream -> message ID -> due time
+}
+
+func newRetryScheduler() *retryScheduler {
+	return &retryScheduler{
+		entries: make(map[string]map[string]time.Time),
+	}
+}
+
+func (s *retryScheduler) schedule(stream, id string, due time.Time) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	ids, ok := s.entries[stream]
+	if !ok {
+		ids = make(map[string]time.Time)
+		s.entries[stream] = ids
+	}
+	ids[id] = due
+}
+
+func (s *retryScheduler) contains(stream, id string) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if ids, ok := s.entries[stream]; ok {
+		_, ok = ids[id]
+		return ok
+	}
+	return false
+}
+
+// hasStream reports whether any message of the stream is waiting for retry.
+func (s *retryScheduler) hasStream(stream string) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return len(s.entries[stream]) > 0
+}
+
+func (s *retryScheduler) count() int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	var count int
+	for _, ids := range s.entries {
+		count += len(ids)
+	}
+	return count
+}
+
+// due removes and returns at most limit message IDs which are due at
+// the specified time, grouped by stream. The limit <= 0 means unlimited.
+func (s *retryScheduler) due(now time.Time, limit int) map[string][]string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	var (
+		reply map[string][]string
+		count int
+	)
+	for stream, ids := range s.entries {
+		for id, due := range ids {
+			if due.After(now) {
+				continue
+			}
+			if limit > 0 && count >= limit {
+				break
+			}
+			count++
+
+			if reply == nil {
+				reply = make(map[string][]string)
+			}
+			reply[stream] = append(reply[stream], id)
+			delete(ids, id)
+		}
+		if len(ids) == 0 {
+			delete(s.entries, stream)
+		}
+	}
+	return reply
+}
@@ -0,0 +1,90 @@
+package redis
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+)
+
+func TestRetryScheduler(t *testing.T) {
+	var (
+		now       = time.Now()
+		scheduler = newRetryScheduler()
+	)
+
+	scheduler.schedule("gotestStream1", "1-0", now.Add(-1*time.Second))
+	scheduler.schedule("gotestStream1", "2-0", now.Add(1*time.Second))
+	scheduler.schedule("gotestStream2", "1-0", now)
+	scheduler.schedule("gotestStream2", "3-0", now.Add(-2*time.Second))
+
+	{
+		var expectedCount int = 4
+		if expectedCount != scheduler.count() {
+			t.Errorf("retryScheduler.count() expect:: %v, got:: %v\n", expectedCount, scheduler.count())
+		}
+		if !scheduler.contains("gotestStream1", "2-0") {
+			t.Errorf("retryScheduler.contains() should be true\n")
+		}
+		if scheduler.contains("gotestStream1", "3-0") {
+			t.Errorf("retryScheduler.contains() should be false\n")
+		}
+		if !scheduler.hasStream("gotestStream2") {
+			t.Errorf("retryScheduler.hasStream() should be true\n")
+		}
+		if scheduler.hasStream("gotestStream3") {
+			t.Errorf("retryScheduler.hasStream() should be false\n")
+		}
+	}
+
+	due := scheduler.due(now, 0)
+	for _, ids := range due {
+		sort.Strings(ids)
+	}
+	{
+		expectedDue := map[string][]string{
+			"gotestStream1": {"1-0"},
+			"gotestStream2": {"1-0", "3-0"},
+		}
+		if !reflect.DeepEqual(expectedDue, due) {
+			t.Errorf("retryScheduler.due() expect:: %v, got:: %v\n", expectedDue, due)
+		}
+		var expectedCount int = 1
+		if expectedCount != scheduler.count() {
+			t.Errorf("retryScheduler.count() expect:: %v, got:: %v\n", expectedCount, scheduler.count())
+		}
+	}
+
+	due = scheduler.due(now, 0)
+	if due != nil {
+		t.Errorf("retryScheduler.due() expect:: %v, got:: %v\n", nil, due)
+	}
+}
+
+func TestRetryScheduler_WithLimit(t *testing.T) {
+	var (
+		now       = time.Now()
+		scheduler = newRetryScheduler()
+	)
+
+	scheduler.schedule("gotestStream1", "1-0", now)
+	scheduler.schedule("gotestStream1", "2-0", now)
+	scheduler.schedule("gotestStream1", "3-0", now)
+
+	due := scheduler.due(now, 2)
+	{
+		var expectedDueCount int = 2
+		if expectedDueCount != len(due["gotestStream1"]) {
+			t.Errorf("retryScheduler.due() expect %d IDs, got:: %v\n", expectedDueCount, due)
+		}
+		var expectedCount int = 1
+		if expectedCount != scheduler.count() {
+			t.Errorf("retryScheduler.count() expect:: %v, got:: %v\n", expectedCount, scheduler.count())
+		}
+	}
+
+	due = scheduler.due(now, 2)
+	if due == nil {
+		t.Errorf("retryScheduler.due() should not be nil\n")
+	}
+}
@@ -0,0 +1,40 @@
+package redis
+
+type StreamInfo struct {
+	Length               int64
+	RadixTreeKeys        int64
+	RadixTreeNodes       int64
+	Groups               int64
+	LastGeneratedID      string
+	MaxDeletedEntryID    string    // Redis 7.0 起提供
+	EntriesAdded         int64     // Redis 7.0 起提供, 無法取得時為 -1
+	RecordedFirstEntryID string    // Redis 7.0 起提供
+	FirstEntry           *XMessage // stream 為空時為 nil
+	LastEntry            *XMessage // stream 為空時為 nil
+}
+
+func parseStreamInfo(reply interface{}) (*StreamInfo, error) {
+	info, err := parseInfoReply(reply)
+	if err != nil {
+		return nil, err
+	}
+
+	v := &StreamInfo{
+		LastGeneratedID:      infoString(info, "last-generated-id"),
+		MaxDeletedEntryID:    infoString(info, "max-deleted-entry-id"),
+		EntriesAdded:         infoInt64OrDefault(info, "entries-added", -1),
+		RecordedFirstEntryID: infoString(info, "recorded-first-entry-id"),
+	}
+	v.Length, _ = infoInt64(info, "length")
+	v.RadixTreeKeys, _ = infoInt64(info, "radix-tree-keys")
+	v.RadixTreeNodes, _ = infoInt64(info, "radix-tree-nodes")
+	v.Groups, _ = infoInt64(info, "groups")
+
+	if entry, ok := parseXMessageReply(info["first-entry"]); ok {
+		v.FirstEntry = &entry
+	}
+	if entry, ok := parseXMessageReply(info["last-entry"]); ok {
+		v.LastEntry = &entry
+	}
+	return v, nil
+}
@@ -0,0 +1,147 @@
+package redis
+
+import (
+	"fmt"
+	"time"
+)
+
+type StreamInfoFull struct {
+	Length               int64
+	RadixTreeKeys        int64
+	RadixTreeNodes       int64
+	LastGeneratedID      string
+	MaxDeletedEntryID    string // Redis 7.0 起提供
+	EntriesAdded         int64  // Redis 7.0 起提供, 無法取得時為 -1
+	RecordedFirstEntryID string // Redis 7.0 起提供
+	Entries              []XMessage
+	Groups               []GroupInfoFull
+}
+
+type GroupInfoFull struct {
+	Name            string
+	LastDeliveredID string
+	EntriesRead     int64 // Redis 7.0 起提供, 無法取得時為 -1
+	Lag             int64 // Redis 7.0 起提供, 無法取得時為 -1
+	PelCount        int64
+	Pending         []PendingEntryInfo
+	Consumers       []ConsumerInfoFull
+}
+
+type ConsumerInfoFull struct {
+	Name       string
+	SeenTime   time.Time // 上次嘗試互動的時間
+	ActiveTime time.Time // 上次成功互動的時間, Redis 7.2 起提供
+	PelCount   int64
+	Pending    []PendingEntryInfo
+}
+
+type PendingEntryInfo struct {
+	ID            string
+	Consumer      string // 僅 group 的 pending 清單提供
+	DeliveryTime  time.Time
+	DeliveryCount int64
+}
+
+func parseStreamInfoFull(reply interface{}) (*StreamInfoFull, error) {
+	info, err := parseInfoReply(reply)
+	if err != nil {
+		return nil, err
+	}
+
+	v := &StreamInfoFull{
+		LastGeneratedID:      infoString(info, "last-generated-id"),
+		MaxDeletedEntryID:    infoString(info, "max-deleted-entry-id"),
+		EntriesAdded:         infoInt64OrDefault(info, "entries-added", -1),
+		RecordedFirstEntryID: infoString(info, "recorded-first-entry-id"),
+	}
+	v.Length, _ = infoInt64(info, "length")
+	v.RadixTreeKeys, _ = infoInt64(info, "radix-tree-keys")
+	v.RadixTreeNodes, _ = infoInt64(info, "radix-tree-nodes")
+
+	entries, _ := info["entries"].([]interface{})
+	for _, entry := range entries {
+		if message, ok := parseXMessageReply(entry); ok {
+			v.Entries = append(v.Entries, message)
+		}
+	}
+
+	groups, _ := info["groups"].([]interface{})
+	for _, groupReply := range groups {
+		group, err := parseGroupInfoFull(groupReply)
+		if err != nil {
+			return nil, err
+		}
+		v.Groups = append(v.Groups, *group)
+	}
+	return v, nil
+}
+
+func parseGroupInfoFull(reply interface{}) (*GroupInfoFull, error) {
+	info, err := parseInfoReply(reply)
+	if err != nil {
+		return nil, err
+	}
+
+	v := &GroupInfoFull{
+		Name:            infoString(info, "name"),
+		LastDeliveredID: infoString(info, "last-delivered-id"),
+		EntriesRead:     infoInt64OrDefault(info, "entries-read", -1),
+		Lag:             infoInt64OrDefault(info, "lag", -1),
+	}
+	v.PelCount, _ = infoInt64(info, "pel-count")
+
+	// pending entry: [id, consumer, delivery-time, delivery-count]
+	pending, _ := info["pending"].([]interface{})
+	for _, entry := range pending {
+		fields, ok := entry.([]interface{})
+		if !ok || len(fields) != 4 {
+			return nil, fmt.Errorf("unexpected pending entry %v", entry)
+		}
+		v.Pending = append(v.Pending, parsePendingEntryInfo(fields[0], fields[1], fields[2], fields[3]))
+	}
+
+	consumers, _ := info["consumers"].([]interface{})
+	for _, consumerReply := range consumers {
+		consumer, err := parseConsumerInfoFull(consumerReply)
+		if err != nil {
+			return nil, err
+		}
+		v.Consumers = append(v.Consumers, *consumer)
+	}
+	return v, nil
+}
+
+func parseConsumerInfoFull(reply interface{}) (*ConsumerInfoFull, error) {
+	info, err := parseInfoReply(reply)
+	if err != nil {
+		return nil, err
+	}
+
+	v := &ConsumerInfoFull{
+		Name:       infoString(info, "name"),
+		SeenTime:   infoUnixMilli(info, "seen-time"),
+		ActiveTime: infoUnixMilli(info, "active-time"),
+	}
+	v.PelCount, _ = infoInt64(info, "pel-count")
+
+	// pending entry: [id, delivery-time, delivery-count]
+	pending, _ := info["pending"].([]interface{})
+	for _, entry := range pending {
+		fields, ok := entry.([]interface{})
+		if !ok || len(fields) != 3 {
+			return nil, fmt.Errorf("unexpected pending entry %v", entry)
+		}
+		v.Pending = append(v.Pending, parsePendingEntryInfo(fields[0], v.Name, fields[1], fields[2]))
+	}
+	return v, nil
+}
+
+func parsePendingEntryInfo(id, consumer, deliveryTime, deliveryCount interface{}) PendingEntryInfo {
+	v := PendingEntryInfo{
+		DeliveryTime: replyUnixMilli(deliveryTime),
+	}
+	v.ID, _ = id.(string)
+	v.Consumer, _ = consumer.(string)
+	v.DeliveryCount, _ = replyInt64(deliveryCount)
+	return v
+}
@@ -0,0 +1,108 @@
+package redis
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseStreamInfoFull(t *testing.T) {
+	reply := []interface{}{
+		"length", int64(2),
+		"radix-tree-keys", int64(1),
+		"radix-tree-nodes", int64(2),
+		"last-generated-id", "1638125141232-1",
+		"max-deleted-entry-id", "0-0",
+		"entries-added", int64(2),
+		"recorded-first-entry-id", "1638125141232-0",
+		"entries", []interface{}{
+			[]interface{}{"1638125141232-0", []interface{}{"name", "luffy"}},
+			[]interface{}{"1638125141232-1", []interface{}{"name", "nami"}},
+		},
+		"groups", []interface{}{
+			[]interface{}{
+				"name", "gotestGroup",
+				"last-delivered-id", "1638125141232-0",
+				"entries-read", int64(1),
+				"lag", nil,
+				"pel-count", int64(1),
+				"pending", []interface{}{
+					[]interface{}{"1638125141232-0", "gotestConsumer", int64(1638125153423), int64(2)},
+				},
+				"consumers", []interface{}{
+					[]interface{}{
+						"name", "gotestConsumer",
+						"seen-time", int64(1638125153423),
+						"pel-count", int64(1),
+						"pending", []interface{}{
+							[]interface{}{"1638125141232-0", int64(1638125153423), int64(2)},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	info, err := parseStreamInfoFull(reply)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var expectedLength int64 = 2
+	if expectedLength != info.Length {
+		t.Errorf("StreamInfoFull.Length expect:: %v, got:: %v\n", expectedLength, info.Length)
+	}
+	var expectedLastGeneratedID string = "1638125141232-1"
+	if expectedLastGeneratedID != info.LastGeneratedID {
+		t.Errorf("StreamInfoFull.LastGeneratedID expect:: %v, got:: %v\n", expectedLastGeneratedID, info.LastGeneratedID)
+	}
+	if len(info.Entries) != 2 {
+		t.Fatalf("expect %d entries, but got %d entries", 2, len(info.Entries))
+	}
+	if v := info.Entries[1].Values["name"]; v != "nami" {
+		t.Errorf("StreamInfoFull.Entries[1] expect:: %v, got:: %v\n", "nami", v)
+	}
+	if len(info.Groups) != 1 {
+		t.Fatalf("expect %d groups, but got %d groups", 1, len(info.Groups))
+	}
+
+	group := info.Groups[0]
+	{
+		var expectedEntriesRead int64 = 1
+		if expectedEntriesRead != group.EntriesRead {
+			t.Errorf("GroupInfoFull.EntriesRead expect:: %v, got:: %v\n", expectedEntriesRead, group.EntriesRead)
+		}
+		var expectedLag int64 = -1
+		if expectedLag != group.Lag {
+			t.Errorf("GroupInfoFull.Lag expect:: %v, got:: %v\n", expectedLag, group.Lag)
+		}
+		if len(group.Pending) != 1 {
+			t.Fatalf("expect %d pending entries, but got %d pending entries", 1, len(group.Pending))
+		}
+		var expectedPending = PendingEntryInfo{
+			ID:            "1638125141232-0",
+			Consumer:      "gotestConsumer",
+			DeliveryTime:  time.UnixMilli(1638125153423),
+			DeliveryCount: 2,
+		}
+		if expectedPending != group.Pending[0] {
+			t.Errorf("GroupInfoFull.Pending expect:: %v, got:: %v\n", expectedPending, group.Pending[0])
+		}
+	}
+
+	if len(group.Consumers) != 1 {
+		t.Fatalf("expect %d consumers, but got %d consumers", 1, len(group.Consumers))
+	}
+	consumer := group.Consumers[0]
+	{
+		if !consumer.ActiveTime.IsZero() {
+			t.Errorf("ConsumerInfoFull.ActiveTime should be zero without Redis 7.2, got:: %v\n", consumer.ActiveTime)
+		}
+		if len(consumer.Pending) != 1 {
+			t.Fatalf("expect %d pending entries, but got %d pending entries", 1, len(consumer.Pending))
+		}
+		var expectedConsumer string = "gotestConsumer"
+		if expectedConsumer != consumer.Pending[0].Consumer {
+			t.Errorf("ConsumerInfoFull.Pending[0].Consumer expect:: %v, got:: %v\n", expectedConsumer, consumer.Pending[0].Consumer)
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package redis
+
+var _ StreamOffsetInfo = StreamPattern{}
+
+// StreamPattern subscribes all the streams whose key matches the glob-style
+// Pattern, the matched streams are discovered by SCAN periodically.
+type StreamPattern struct {
+	Pattern string
+	Offset  ConsumerOffset
+}
+
+func StreamMatch(pattern string) StreamPattern {
+	return StreamPattern{
+		Pattern: pattern,
+		Offset:  StreamNeverDeliveredOffset,
+	}
+}
+
+func (p StreamPattern) Stream(stream string) StreamOffset {
+	return StreamOffset{
+		Stream: stream,
+		Offset: p.Offset,
+	}
+}
+
+// getStreamOffset implements StreamOffsetInfo.
+func (p StreamPattern) getStreamOffset() StreamOffset {
+	return StreamOffset{
+		Stream: p.Pattern,
+		Offset: p.Offset,
+	}
+}
+
+func splitStreamPatterns(streams []StreamOffsetInfo) ([]StreamOffsetInfo, []StreamPattern) {
+	var (
+		offsets  = make([]StreamOffsetInfo, 0, len(streams))
+		patterns []StreamPattern
+	)
+	for _, s := range streams {
+		if p, ok := s.(StreamPattern); ok {
+			patterns = append(patterns, p)
+			continue
+		}
+		offsets = append(offsets, s)
+	}
+	return offsets, patterns
+}
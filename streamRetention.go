@@ -0,0 +1,61 @@
+package redis
+
+import (
+	"fmt"
+	"time"
+)
+
+// StreamRetention trims the stream on XADD, only one of MaxLen, MinID
+// and MaxAge can be set.
+type StreamRetention struct {
+	MaxLen      int64         // 保留的訊息數上限
+	MinID       string        // 移除 ID 小於 MinID 的訊息, Redis 6.2 起提供
+	MaxAge      time.Duration // 移除寫入時間早於 MaxAge 之前的訊息, 以 MINID 實作
+	Approximate bool          // 以 ~ 近似修剪, 效能較佳但可能保留稍多的訊息
+}
+
+func (r StreamRetention) IsZero() bool {
+	return r.MaxLen == 0 && len(r.MinID) == 0 && r.MaxAge == 0
+}
+
+func (r StreamRetention) validate() error {
+	var n int
+	if r.MaxLen != 0 {
+		n++
+	}
+	if len(r.MinID) > 0 {
+		n++
+	}
+	if r.MaxAge != 0 {
+		n++
+	}
+	if n > 1 {
+		return fmt.Errorf("only one of MaxLen, MinID and MaxAge of StreamRetention can be set")
+	}
+	if r.MaxLen < 0 {
+		return fmt.Errorf("invalid StreamRetention.MaxLen %d", r.MaxLen)
+	}
+	if r.MaxAge < 0 {
+		return fmt.Errorf("invalid StreamRetention.MaxAge %v", r.MaxAge)
+	}
+	return nil
+}
+
+// args returns the MAXLEN or MINID arguments, the MINID of MaxAge is
+// computed from the time now.
+func (r StreamRetention) args(now time.Time) ([]interface{}, error) {
+	if err := r.validate(); err != nil {
+		return nil, err
+	}
+
+	switch {
+	case r.MaxLen > 0:
+		return trimArgs("maxlen", r.MaxLen, r.Approximate), nil
+	case len(r.MinID) > 0:
+		return trimArgs("minid", r.MinID, r.Approximate), nil
+	case r.MaxAge > 0:
+		minID := fmt.Sprintf("%d-0", now.Add(-r.MaxAge).UnixMilli())
+		return trimArgs("minid", minID, r.Approximate), nil
+	}
+	return nil, nil
+}
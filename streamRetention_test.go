@@ -0,0 +1,76 @@
+package redis
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestStreamRetention(t *testing.T) {
+	now := time.UnixMilli(1700000000000)
+
+	cases := []struct {
+		retention StreamRetention
+		expected  []interface{}
+	}{
+		{StreamRetention{}, nil},
+		{StreamRetention{MaxLen: 1000}, []interface{}{"maxlen", int64(1000)}},
+		{StreamRetention{MaxLen: 1000, Approximate: true}, []interface{}{"maxlen", "~", int64(1000)}},
+		{StreamRetention{MinID: "1699999999000-0"}, []interface{}{"minid", "1699999999000-0"}},
+		{StreamRetention{MaxAge: 24 * time.Hour, Approximate: true}, []interface{}{"minid", "~", "1699913600000-0"}},
+	}
+	for _, c := range cases {
+		args, err := c.retention.args(now)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(c.expected, args) {
+			t.Errorf("StreamRetention.args() expect:: %v, got:: %v\n", c.expected, args)
+		}
+	}
+
+	for _, retention := range []StreamRetention{
+		{MaxLen: 1000, MinID: "0-1"},
+		{MinID: "0-1", MaxAge: time.Hour},
+		{MaxLen: -1},
+	} {
+		if _, err := retention.args(now); err == nil {
+			t.Errorf("StreamRetention.args() should return error with %+v", retention)
+		}
+	}
+}
+
+func TestNewXAddCmd(t *testing.T) {
+	setting := XAddSetting{
+		Retention: StreamRetention{
+			MaxLen: 100,
+		},
+	}
+	for _, opt := range []ProduceMessageXAddOption{
+		WithApproximateTrim(),
+		WithNoMkStream(),
+	} {
+		opt.applyXAdd(&setting)
+	}
+
+	cmd, err := newXAddCmd("gotestStream", StreamAsteriskID, map[string]interface{}{"name": "luffy"}, &setting)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var expectedArgs = []interface{}{"xadd", "gotestStream", "nomkstream", "maxlen", "~", int64(100), "*", "name", "luffy"}
+	if !reflect.DeepEqual(expectedArgs, cmd.Args()) {
+		t.Errorf("newXAddCmd() expect:: %v, got:: %v\n", expectedArgs, cmd.Args())
+	}
+
+	// WithMinID overrides the retention but keeps the approximate mode
+	WithMinID("0-1").applyXAdd(&setting)
+	if setting.Retention.MaxLen != 0 || setting.Retention.MinID != "0-1" || !setting.Retention.Approximate {
+		t.Errorf("WithMinID() got:: %+v\n", setting.Retention)
+	}
+
+	WithoutTrim().applyXAdd(&setting)
+	if !setting.Retention.IsZero() {
+		t.Errorf("WithoutTrim() got:: %+v\n", setting.Retention)
+	}
+}
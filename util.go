@@ -1,7 +1,13 @@
 package redis
 
 import (
+	"context"
 	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
 
 	redis "github.com/go-redis/redis/v7"
 )
@@ -21,8 +27,388 @@ func createRedisUniversalClient(opt *UniversalOptions) (UniversalClient, error)
 	return client, nil
 }
 
+func withContext(client UniversalClient, ctx context.Context) UniversalClient {
+	switch v := client.(type) {
+	case *redis.Client:
+		return v.WithContext(ctx)
+	case *redis.ClusterClient:
+		return v.WithContext(ctx)
+	}
+	return client
+}
+
 func assertCompatibility(condition bool, message string) {
 	if !condition {
 		panic(fmt.Sprintf("unsupported Redis version. %s", message))
 	}
 }
+
+type xAutoClaimReply struct {
+	cursor     string
+	messages   []redis.XMessage
+	ghostIDs   []string // the deleted entries replied with nil fields (Redis 6.2)
+	deletedIDs []string // the deleted entries purged by the server (Redis 7+)
+}
+
+func parseXAutoClaimReply(reply interface{}) (*xAutoClaimReply, error) {
+	values, ok := reply.([]interface{})
+	if !ok || len(values) < 2 {
+		return nil, fmt.Errorf("redis: unexpected XAUTOCLAIM reply %v", reply)
+	}
+
+	result := &xAutoClaimReply{}
+	if result.cursor, ok = values[0].(string); !ok {
+		return nil, fmt.Errorf("redis: unexpected XAUTOCLAIM cursor %v", values[0])
+	}
+
+	entries, _ := values[1].([]interface{})
+	for _, entry := range entries {
+		fields, ok := entry.([]interface{})
+		if !ok || len(fields) != 2 {
+			continue
+		}
+
+		id, ok := fields[0].(string)
+		if !ok {
+			continue
+		}
+		if fields[1] == nil {
+			result.ghostIDs = append(result.ghostIDs, id)
+			continue
+		}
+
+		kv, _ := fields[1].([]interface{})
+		messageValues := make(map[string]interface{}, len(kv)/2)
+		for i := 0; i+1 < len(kv); i += 2 {
+			if k, ok := kv[i].(string); ok {
+				messageValues[k] = kv[i+1]
+			}
+		}
+		result.messages = append(result.messages, redis.XMessage{
+			ID:     id,
+			Values: messageValues,
+		})
+	}
+
+	if len(values) > 2 {
+		deleted, _ := values[2].([]interface{})
+		for _, v := range deleted {
+			if id, ok := v.(string); ok {
+				result.deletedIDs = append(result.deletedIDs, id)
+			}
+		}
+	}
+	return result, nil
+}
+
+// scanStreamKeys returns the keys of the streams which match the pattern.
+// The TYPE option of SCAN is available since Redis 6.0, the key types are
+// checked with TYPE otherwise.
+func scanStreamKeys(client redis.UniversalClient, pattern string, count int64, withType bool) ([]string, error) {
+	var (
+		keys   []string
+		cursor uint64
+	)
+	for {
+		args := []interface{}{"scan", cursor, "match", pattern, "count", count}
+		if withType {
+			args = append(args, "type", "stream")
+		}
+		cmd := redis.NewScanCmd(client.Process, args...)
+		_ = client.Process(cmd)
+
+		page, next, err := cmd.Result()
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, page...)
+
+		cursor = next
+		if cursor == 0 {
+			break
+		}
+	}
+
+	if withType || len(keys) == 0 {
+		return keys, nil
+	}
+
+	pipe := client.Pipeline()
+	cmds := make([]*redis.StatusCmd, len(keys))
+	for i, key := range keys {
+		cmds[i] = pipe.Type(key)
+	}
+	if _, err := pipe.Exec(); err != nil {
+		return nil, err
+	}
+
+	var streamKeys = make([]string, 0, len(keys))
+	for i, cmd := range cmds {
+		if cmd.Val() == "stream" {
+			streamKeys = append(streamKeys, keys[i])
+		}
+	}
+	return streamKeys, nil
+}
+
+// matchPattern reports whether the s matches the glob-style pattern the
+// same as the MATCH option of SCAN, it supports *, ?, [...] and \ escaping.
+func matchPattern(pattern, s string) bool {
+	for len(pattern) > 0 {
+		switch pattern[0] {
+		case '*':
+			for len(pattern) > 1 && pattern[1] == '*' {
+				pattern = pattern[1:]
+			}
+			if len(pattern) == 1 {
+				return true
+			}
+			for i := 0; i <= len(s); i++ {
+				if matchPattern(pattern[1:], s[i:]) {
+					return true
+				}
+			}
+			return false
+		case '?':
+			if len(s) == 0 {
+				return false
+			}
+			s = s[1:]
+		case '[':
+			if len(s) == 0 {
+				return false
+			}
+			pattern = pattern[1:]
+
+			var not, matched bool
+			if len(pattern) > 0 && pattern[0] == '^' {
+				not = true
+				pattern = pattern[1:]
+			}
+			for len(pattern) > 0 && pattern[0] != ']' {
+				switch {
+				case pattern[0] == '\\' && len(pattern) >= 2:
+					pattern = pattern[1:]
+					if pattern[0] == s[0] {
+						matched = true
+					}
+				case len(pattern) >= 3 && pattern[1] == '-':
+					start, end := pattern[0], pattern[2]
+					if start > end {
+						start, end = end, start
+					}
+					if s[0] >= start && s[0] <= end {
+						matched = true
+					}
+					pattern = pattern[2:]
+				default:
+					if pattern[0] == s[0] {
+						matched = true
+					}
+				}
+				pattern = pattern[1:]
+			}
+			if not {
+				matched = !matched
+			}
+			if !matched {
+				return false
+			}
+			s = s[1:]
+			// unterminated bracket
+			if len(pattern) == 0 {
+				return len(s) == 0
+			}
+		case '\\':
+			if len(pattern) >= 2 {
+				pattern = pattern[1:]
+			}
+			fallthrough
+		default:
+			if len(s) == 0 || pattern[0] != s[0] {
+				return false
+			}
+			s = s[1:]
+		}
+		pattern = pattern[1:]
+	}
+	return len(s) == 0
+}
+
+// parseInfoReply converts the flat field-value reply of XINFO into a map.
+func parseInfoReply(reply interface{}) (map[string]interface{}, error) {
+	values, ok := reply.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("got %T, wanted []interface{}", reply)
+	}
+	if len(values)%2 != 0 {
+		return nil, fmt.Errorf("got %d fields, wanted field-value pairs", len(values))
+	}
+
+	var info = make(map[string]interface{}, len(values)/2)
+	for i := 0; i < len(values); i += 2 {
+		field, ok := values[i].(string)
+		if !ok {
+			return nil, fmt.Errorf("got %T, wanted string as field name", values[i])
+		}
+		info[field] = values[i+1]
+	}
+	return info, nil
+}
+
+func infoInt64(info map[string]interface{}, field string) (int64, bool) {
+	return replyInt64(info[field])
+}
+
+func replyInt64(reply interface{}) (int64, bool) {
+	switch v := reply.(type) {
+	case int64:
+		return v, true
+	case string:
+		n, err := strconv.ParseInt(v, 10, 64)
+		return n, err == nil
+	}
+	return 0, false
+}
+
+func infoString(info map[string]interface{}, field string) string {
+	if v, ok := info[field].(string); ok {
+		return v
+	}
+	return ""
+}
+
+// nextStreamID returns the smallest ID greater than the id.
+func nextStreamID(id string) (string, error) {
+	ms, seq, ok := strings.Cut(id, "-")
+	if !ok {
+		seq = "0"
+	}
+	n, err := strconv.ParseUint(seq, 10, 64)
+	if err != nil {
+		return "", fmt.Errorf("invalid stream ID '%s'", id)
+	}
+	if n == math.MaxUint64 {
+		m, err := strconv.ParseUint(ms, 10, 64)
+		if err != nil {
+			return "", fmt.Errorf("invalid stream ID '%s'", id)
+		}
+		return fmt.Sprintf("%d-0", m+1), nil
+	}
+	return fmt.Sprintf("%s-%d", ms, n+1), nil
+}
+
+func infoInt64OrDefault(info map[string]interface{}, field string, value int64) int64 {
+	if v, ok := infoInt64(info, field); ok {
+		return v
+	}
+	return value
+}
+
+func infoMilliseconds(info map[string]interface{}, field string) (time.Duration, bool) {
+	v, ok := infoInt64(info, field)
+	return time.Duration(v) * time.Millisecond, ok
+}
+
+func infoUnixMilli(info map[string]interface{}, field string) time.Time {
+	return replyUnixMilli(info[field])
+}
+
+func replyUnixMilli(reply interface{}) time.Time {
+	if v, ok := replyInt64(reply); ok && v > 0 {
+		return time.UnixMilli(v)
+	}
+	return time.Time{}
+}
+
+// parseXMessageReply parses the entry reply which is an array of the ID and
+// the field-value pairs, it returns false if the entry has been deleted.
+func parseXMessageReply(reply interface{}) (XMessage, bool) {
+	fields, ok := reply.([]interface{})
+	if !ok || len(fields) != 2 {
+		return XMessage{}, false
+	}
+
+	id, ok := fields[0].(string)
+	if !ok {
+		return XMessage{}, false
+	}
+	kv, ok := fields[1].([]interface{})
+	if !ok {
+		return XMessage{}, false
+	}
+
+	values := make(map[string]interface{}, len(kv)/2)
+	for i := 0; i+1 < len(kv); i += 2 {
+		if k, ok := kv[i].(string); ok {
+			values[k] = kv[i+1]
+		}
+	}
+	return XMessage{
+		ID:     id,
+		Values: values,
+	}, true
+}
+
+// trimArgs returns the MAXLEN or MINID arguments of XTRIM and XADD.
+func trimArgs(strategy string, threshold interface{}, approximate bool) []interface{} {
+	if approximate {
+		return []interface{}{strategy, "~", threshold}
+	}
+	return []interface{}{strategy, threshold}
+}
+
+// newXAddCmd builds XADD with the NOMKSTREAM, MAXLEN and MINID arguments
+// which are not supported by redis.XAddArgs. The idempotent XADD is sent
+// by EVAL if the idempotency key is set.
+func newXAddCmd(stream, id string, values map[string]interface{}, setting *XAddSetting) (*redis.StringCmd, error) {
+	args := make([]interface{}, 0, 7+len(values)*2)
+	if setting != nil && len(setting.IdempotencyKey) > 0 {
+		args = append(args, "eval", idempotentXAddScript, 2,
+			stream, producedKey(stream, setting.IdempotencyKey),
+			setting.IdempotencyTTL.Milliseconds())
+	} else {
+		args = append(args, "xadd", stream)
+	}
+	if setting != nil {
+		if setting.NoMkStream {
+			args = append(args, "nomkstream")
+		}
+		trim, err := setting.Retention.args(time.Now())
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, trim...)
+	}
+	if len(id) == 0 {
+		id = StreamAsteriskID
+	}
+	args = append(args, id)
+	for k, v := range values {
+		args = append(args, k, v)
+	}
+	return redis.NewStringCmd(args...), nil
+}
+
+// sizeOf returns the size of the value written to Redis.
+func sizeOf(v interface{}) int {
+	switch v := v.(type) {
+	case string:
+		return len(v)
+	case []byte:
+		return len(v)
+	}
+	return len(fmt.Sprint(v))
+}
+
+// truncateString cuts the s to at most size bytes without breaking the
+// UTF-8 characters.
+func truncateString(s string, size int) string {
+	if len(s) <= size {
+		return s
+	}
+	for size > 0 && !utf8.RuneStart(s[size]) {
+		size--
+	}
+	return s[:size]
+}
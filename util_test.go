@@ -0,0 +1,148 @@
+package redis
+
+import (
+	"reflect"
+	"testing"
+
+	redis "github.com/go-redis/redis/v7"
+)
+
+func TestParseXAutoClaimReply(t *testing.T) {
+	reply := []interface{}{
+		"1002-0",
+		[]interface{}{
+			[]interface{}{"1000-0", []interface{}{"name", "luffy", "age", "19"}},
+			[]interface{}{"1001-0", nil},
+		},
+		[]interface{}{"999-0"},
+	}
+
+	result, err := parseXAutoClaimReply(reply)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var expectedCursor string = "1002-0"
+	if expectedCursor != result.cursor {
+		t.Errorf("xAutoClaimReply.cursor expect:: %v, got:: %v\n", expectedCursor, result.cursor)
+	}
+	expectedMessages := []redis.XMessage{
+		{ID: "1000-0", Values: map[string]interface{}{"name": "luffy", "age": "19"}},
+	}
+	if !reflect.DeepEqual(expectedMessages, result.messages) {
+		t.Errorf("xAutoClaimReply.messages expect:: %v, got:: %v\n", expectedMessages, result.messages)
+	}
+	expectedGhostIDs := []string{"1001-0"}
+	if !reflect.DeepEqual(expectedGhostIDs, result.ghostIDs) {
+		t.Errorf("xAutoClaimReply.ghostIDs expect:: %v, got:: %v\n", expectedGhostIDs, result.ghostIDs)
+	}
+	expectedDeletedIDs := []string{"999-0"}
+	if !reflect.DeepEqual(expectedDeletedIDs, result.deletedIDs) {
+		t.Errorf("xAutoClaimReply.deletedIDs expect:: %v, got:: %v\n", expectedDeletedIDs, result.deletedIDs)
+	}
+}
+
+func TestParseXAutoClaimReply_WithInvalidReply(t *testing.T) {
+	for _, reply := range []interface{}{
+		nil,
+		"OK",
+		[]interface{}{"0-0"},
+		[]interface{}{int64(0), []interface{}{}},
+	} {
+		_, err := parseXAutoClaimReply(reply)
+		if err == nil {
+			t.Errorf("parseXAutoClaimReply(%v) should return error", reply)
+		}
+	}
+}
+
+func TestMatchPattern(t *testing.T) {
+	expectedAndGot := [][]bool{
+		// run matchPattern()                                , expected
+		{matchPattern("orders:*", "orders:tw"), true},
+		{matchPattern("orders:*", "orders:"), true},
+		{matchPattern("orders:*", "order:tw"), false},
+		{matchPattern("*:tw", "orders:tw"), true},
+		{matchPattern("orders:??", "orders:tw"), true},
+		{matchPattern("orders:??", "orders:twn"), false},
+		{matchPattern("orders:[a-z]w", "orders:tw"), true},
+		{matchPattern("orders:[^t]w", "orders:tw"), false},
+		{matchPattern("orders:[jpt]w", "orders:tw"), true},
+		{matchPattern("orders/*", "orders/tw/taipei"), true},
+		{matchPattern("orders\\*", "orders*"), true},
+		{matchPattern("orders\\*", "orders:tw"), false},
+		{matchPattern("orders:[tw", "orders:t"), true},
+		{matchPattern("orders", "orders"), true},
+	}
+
+	for i, v := range expectedAndGot {
+		expected, got := v[1], v[0]
+		if expected != got {
+			t.Errorf("assert matchPattern() at %d :: expected %+v, got %+v", i, expected, got)
+		}
+	}
+}
+
+func TestNextStreamID(t *testing.T) {
+	for id, expected := range map[string]string{
+		"1000-0":                    "1000-1",
+		"1000-41":                   "1000-42",
+		"1000":                      "1000-1",
+		"1000-18446744073709551615": "1001-0",
+	} {
+		got, err := nextStreamID(id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if expected != got {
+			t.Errorf("nextStreamID(%q) expect:: %v, got:: %v\n", id, expected, got)
+		}
+	}
+
+	if _, err := nextStreamID("1000-x"); err == nil {
+		t.Errorf("nextStreamID() should return error with invalid ID")
+	}
+}
+
+func TestTruncateString(t *testing.T) {
+	for _, c := range []struct {
+		s        string
+		size     int
+		expected string
+	}{
+		{"luffy", 8, "luffy"},
+		{"luffy", 3, "luf"},
+		{"魯夫", 4, "魯"},
+		{"魯夫", 2, ""},
+	} {
+		if got := truncateString(c.s, c.size); c.expected != got {
+			t.Errorf("truncateString(%q, %d) expect:: %v, got:: %v\n", c.s, c.size, c.expected, got)
+		}
+	}
+}
+
+func TestParseInfoReply(t *testing.T) {
+	info, err := parseInfoReply([]interface{}{
+		"name", "gotestGroup",
+		"pending", int64(2),
+		"lag", nil,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if v := infoString(info, "name"); v != "gotestGroup" {
+		t.Errorf("infoString() expect:: %v, got:: %v\n", "gotestGroup", v)
+	}
+	if v, ok := infoInt64(info, "pending"); !ok || v != 2 {
+		t.Errorf("infoInt64() expect:: %v, got:: %v\n", 2, v)
+	}
+	if _, ok := infoInt64(info, "lag"); ok {
+		t.Errorf("infoInt64() should not be ok with nil value")
+	}
+
+	_, err = parseInfoReply([]interface{}{"name"})
+	if err == nil {
+		t.Errorf("parseInfoReply() should return error with odd fields")
+	}
+}
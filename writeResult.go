@@ -0,0 +1,6 @@
+package redis
+
+type WriteResult struct {
+	ID  string // 寫入成功時的訊息 ID
+	Err error  // 寫入失敗的原因
+}
@@ -0,0 +1,34 @@
+package redis
+
+import (
+	redis "github.com/go-redis/redis/v7"
+	oteltrace "go.opentelemetry.io/otel/trace"
+)
+
+// xaddRequest is the XADD of a message in the batch.
+type xaddRequest struct {
+	stream  string
+	id      string
+	values  map[string]interface{}
+	setting XAddSetting
+	cmd     *redis.StringCmd
+	span    oteltrace.Span
+}
+
+// reset builds a new XADD command for sending or retrying.
+func (r *xaddRequest) reset() error {
+	cmd, err := newXAddCmd(r.stream, r.id, r.values, &r.setting)
+	if err != nil {
+		return err
+	}
+	r.cmd = cmd
+	return nil
+}
+
+func (r *xaddRequest) end(id string, err error) {
+	if r == nil || r.span == nil {
+		return
+	}
+	endProducerSpan(r.span, id, r.values, err)
+	r.span.End()
+}